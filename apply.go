@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/AlecAivazis/survey/v2"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// server-side apply 使用的 field manager
+const fieldManager = "kube-ui"
+
+// 待应用的对象
+type applyItem struct {
+	ref      resourceRef
+	obj      *unstructured.Unstructured
+	exists   bool
+	selected bool
+}
+
+func handleApplyFileAction(path string, selected *resourceRef) {
+	if err := applyFile(path, selected, false); err != nil {
+		fmt.Println(err)
+	}
+}
+
+// 解析本地文件, dry-run 展示差异, 确认后使用 server-side apply 应用
+// selected 为当前选中的对象, 文件中有其他对象时给出提示
+// yes 为 true 时不询问: 只应用选中的对象, 字段冲突时不强制覆盖
+func applyFile(path string, selected *resourceRef, yes bool) error {
+	objects, err := readManifestObjects(path)
+	if err != nil {
+		return fmt.Errorf("Error reading %s: %v", path, err)
+	}
+	if len(objects) == 0 {
+		return fmt.Errorf("No objects found in %s", path)
+	}
+
+	var items []applyItem
+	others := 0
+	for _, obj := range objects {
+		ref, err := refForObject(obj)
+		if err != nil {
+			return fmt.Errorf("Error: %v", err)
+		}
+		if ref.Namespace != "" {
+			obj.SetNamespace(ref.Namespace)
+		}
+		item := applyItem{ref: ref, obj: obj}
+		if selected != nil {
+			item.selected = ref.GVR == selected.GVR && ref.Namespace == selected.Namespace && ref.Name == selected.Name
+			if !item.selected {
+				others++
+			}
+		}
+		items = append(items, item)
+	}
+
+	// 文件中包含选中对象以外的对象
+	if selected != nil && others > 0 {
+		fmt.Printf("\033[1;33mWarning: %s contains %d object(s) other than %s:\033[0m\n", path, others, selected)
+		for _, item := range items {
+			if !item.selected {
+				fmt.Printf("\033[1;33m  - %s (namespace: %s)\033[0m\n", item.ref, item.ref.Namespace)
+			}
+		}
+		choice := ""
+		options := []string{"apply all objects in file", "cancel"}
+		if len(items) > others {
+			options = []string{"apply only " + selected.String(), "apply all objects in file", "cancel"}
+		}
+		if yes {
+			if len(items) == others {
+				return fmt.Errorf("%s does not contain %s", path, selected)
+			}
+			choice = options[0]
+		} else if err := survey.AskOne(&survey.Select{
+			Message: "choose what to apply:",
+			Options: options,
+		}, &choice); err != nil || choice == "cancel" {
+			return nil
+		}
+		if choice == "apply only "+selected.String() {
+			var onlySelected []applyItem
+			for _, item := range items {
+				if item.selected {
+					onlySelected = append(onlySelected, item)
+				}
+			}
+			items = onlySelected
+		}
+	}
+
+	// dry-run 并展示差异
+	force := false
+	changed := 0
+	for i := range items {
+		hasDiff, err := dryRunApply(&items[i], force)
+		if err != nil && apierrors.IsConflict(err) && !force && !yes {
+			fmt.Printf("Field conflict on %s: %v\n", items[i].ref, err)
+			survey.AskOne(&survey.Confirm{
+				Message: "Take ownership of the conflicting fields (force)?",
+				Default: false,
+			}, &force)
+			if !force {
+				return nil
+			}
+			hasDiff, err = dryRunApply(&items[i], force)
+		}
+		if err != nil {
+			return fmt.Errorf("Dry-run failed for %s: %v", items[i].ref, err)
+		}
+		if hasDiff {
+			changed++
+		}
+	}
+	if changed == 0 {
+		fmt.Println("Nothing to apply, live state already matches the file")
+		return nil
+	}
+
+	confirm := yes
+	if !yes {
+		survey.AskOne(&survey.Confirm{
+			Message: fmt.Sprintf("Apply %d object(s) from %s?", len(items), path),
+			Default: false,
+		}, &confirm)
+	}
+	if !confirm {
+		return nil
+	}
+
+	failed := 0
+	for _, item := range items {
+		if item.exists && !backupBeforeChange(item.ref, "apply") {
+			return fmt.Errorf("apply of %s cancelled, backup failed", item.ref)
+		}
+		ctx, cancel := requestContext()
+		_, err := item.ref.client().Apply(ctx, item.ref.Name, item.obj, metav1.ApplyOptions{
+			FieldManager: fieldManager,
+			Force:        force,
+		})
+		err = requestError(ctx, err)
+		cancel()
+		auditAPI("apply", "apply", item.ref.String(), err)
+		if err != nil {
+			fmt.Printf("Error applying %s: %v\n", item.ref, err)
+			failed++
+			continue
+		}
+		fmt.Printf("%s applied\n", item.ref)
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to apply %d of %d object(s) from %s", failed, len(items), path)
+	}
+	return nil
+}
+
+// server-side apply dry-run, 打印与当前状态的差异
+func dryRunApply(item *applyItem, force bool) (bool, error) {
+	liveText := ""
+	live, err := getLiveObject(item.ref)
+	switch {
+	case err == nil:
+		item.exists = true
+		stripNoiseFields(live)
+		liveText, _ = objectToYAML(live)
+	case apierrors.IsNotFound(err):
+		item.exists = false
+	default:
+		return false, err
+	}
+
+	ctx, cancel := requestContext()
+	defer cancel()
+	result, err := item.ref.client().Apply(ctx, item.ref.Name, item.obj, metav1.ApplyOptions{
+		FieldManager: fieldManager,
+		Force:        force,
+		DryRun:       []string{metav1.DryRunAll},
+	})
+	if err != nil {
+		return false, err
+	}
+	stripNoiseFields(result)
+	resultText, _ := objectToYAML(result)
+
+	fmt.Printf("==============%s======================\n", item.ref)
+	if !item.exists {
+		fmt.Println("\033[0;32m(new object)\033[0m")
+	}
+	return printDiff(liveText, resultText, "live/"+item.ref.String(), "applied/"+item.ref.String()), nil
+}
@@ -0,0 +1,351 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// 审计日志条目, 每行一个 JSON
+type AuditEntry struct {
+	Time      time.Time `json:"time"`
+	User      string    `json:"user"`
+	Config    string    `json:"config,omitempty"`
+	Cluster   string    `json:"cluster"`
+	Context   string    `json:"context"`
+	Namespace string    `json:"namespace"`
+	Resource  string    `json:"resource,omitempty"`
+	Action    string    `json:"action"`
+	Verb      string    `json:"verb"`
+	Args      []string  `json:"args,omitempty"`
+	Result    string    `json:"result"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// 当前会话连接的集群信息, 在创建客户端时初始化
+var auditCluster, auditContext string
+
+var (
+	auditSince    *string
+	auditUntil    *string
+	auditClusterF *string
+	auditVerb     *string
+	auditResource *string
+	auditUser     *string
+	auditRaw      *bool
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Query the kube-ui audit log",
+	Long: `Query the append-only audit log of every action and spawned command, e.g. "kube-ui audit --since 24h --verb restart --resource deployment/api -n prod".
+
+The log is stored in ~/.kube-ui.d/audit.log, ~/.kube-ui itself is the config file.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		since, err := parseAuditTime(*auditSince)
+		if err != nil {
+			fmt.Printf("Invalid --since: %v\n", err)
+			return
+		}
+		until, err := parseAuditTime(*auditUntil)
+		if err != nil {
+			fmt.Printf("Invalid --until: %v\n", err)
+			return
+		}
+		entries, err := readAuditLog(func(e AuditEntry) bool {
+			if !since.IsZero() && e.Time.Before(since) {
+				return false
+			}
+			if !until.IsZero() && e.Time.After(until) {
+				return false
+			}
+			if *auditClusterF != "" && e.Cluster != *auditClusterF && e.Config != *auditClusterF && e.Context != *auditClusterF {
+				return false
+			}
+			if *auditVerb != "" && e.Verb != *auditVerb && e.Action != *auditVerb {
+				return false
+			}
+			if *namespace != "" && e.Namespace != *namespace {
+				return false
+			}
+			if *auditResource != "" && !strings.Contains(e.Resource, *auditResource) {
+				return false
+			}
+			if *auditUser != "" && e.User != *auditUser {
+				return false
+			}
+			return true
+		})
+		if err != nil {
+			fmt.Printf("Error reading audit log: %v\n", err)
+			return
+		}
+		if *auditRaw {
+			for _, e := range entries {
+				data, _ := json.Marshal(e)
+				fmt.Println(string(data))
+			}
+			return
+		}
+		printAuditTable(entries)
+	},
+}
+
+func init() {
+	auditSince = auditCmd.Flags().String("since", "", "only show entries newer than a duration (e.g. 24h) or RFC3339 time")
+	auditUntil = auditCmd.Flags().String("until", "", "only show entries older than a duration (e.g. 1h) or RFC3339 time")
+	auditClusterF = auditCmd.Flags().String("cluster", "", "filter by cluster, context or kube-ui config name")
+	auditVerb = auditCmd.Flags().String("verb", "", "filter by verb or action (e.g. delete, scale, restart)")
+	auditResource = auditCmd.Flags().String("resource", "", "filter by resource, substring match (e.g. deployment/api)")
+	auditUser = auditCmd.Flags().String("user", "", "filter by OS user")
+	auditRaw = auditCmd.Flags().Bool("json", false, "print matching entries as JSON lines")
+
+	rootCmd.AddCommand(auditCmd)
+}
+
+// kube-ui 本地数据目录, ~/.kube-ui 已经是配置文件, 所以使用 ~/.kube-ui.d
+func kubeUIDataDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("error getting home directory: %v", err)
+	}
+	dir := filepath.Join(homeDir, ".kube-ui.d")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("error creating %s: %v", dir, err)
+	}
+	return dir, nil
+}
+
+func auditLogPath() (string, error) {
+	dir, err := kubeUIDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "audit.log"), nil
+}
+
+// 从 kubeconfig 中读取当前的 context 和 cluster
+func initAuditInfo(kubeconfigPath string) {
+	rawConfig, err := clientcmd.LoadFromFile(kubeconfigPath)
+	if err != nil {
+		return
+	}
+	auditContext = rawConfig.CurrentContext
+	if ctx, ok := rawConfig.Contexts[rawConfig.CurrentContext]; ok {
+		auditCluster = ctx.Cluster
+	}
+}
+
+func currentOSUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return os.Getenv("USERNAME")
+}
+
+// 追加一条审计日志, 写入失败只提示不影响操作
+func writeAudit(entry AuditEntry) {
+	entry.Time = time.Now()
+	entry.User = currentOSUser()
+	entry.Config = currentConfig.Name
+	entry.Cluster = auditCluster
+	entry.Context = auditContext
+	if entry.Namespace == "" {
+		entry.Namespace = *namespace
+	}
+
+	path, err := auditLogPath()
+	if err != nil {
+		fmt.Printf("Error writing audit log: %v\n", err)
+		return
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		fmt.Printf("Error writing audit log: %v\n", err)
+		return
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		fmt.Printf("Error writing audit log: %v\n", err)
+		return
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		fmt.Printf("Error writing audit log: %v\n", err)
+	}
+}
+
+func auditResult(entry *AuditEntry, err error) {
+	entry.Result = "success"
+	if err != nil {
+		entry.Result = "error"
+		entry.Error = err.Error()
+	}
+}
+
+// 记录一次外部命令 (kubectl, k9s) 的执行
+func auditCommand(name string, args []string, err error) {
+	entry := AuditEntry{
+		Action: name,
+		Args:   append([]string{name}, args...),
+	}
+	if name == "kubectl" {
+		entry.Verb, entry.Resource = kubectlVerbResource(args)
+	} else {
+		entry.Verb = "exec"
+	}
+	auditResult(&entry, err)
+	writeAudit(entry)
+}
+
+// 记录一次直接通过 API 完成的操作
+func auditAPI(action, verb, resource string, err error) {
+	auditAPINamespace("", action, verb, resource, err)
+}
+
+// 记录一次 API 操作, 对象不在当前 namespace 时使用
+func auditAPINamespace(ns, action, verb, resource string, err error) {
+	entry := AuditEntry{
+		Namespace: ns,
+		Action:    action,
+		Verb:      verb,
+		Resource:  resource,
+	}
+	auditResult(&entry, err)
+	writeAudit(entry)
+}
+
+// 从 kubectl 参数中解析出子命令和操作的资源, 例如 pod/nginx
+func kubectlVerbResource(args []string) (string, string) {
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+		if strings.HasPrefix(arg, "-") {
+			// 这些参数的值是单独的一个参数
+			if arg == "--kubeconfig" || arg == "-n" || arg == "--namespace" || arg == "-o" || arg == "-c" || arg == "-f" {
+				i++
+			}
+			continue
+		}
+		positional = append(positional, arg)
+	}
+	if len(positional) == 0 {
+		return "", ""
+	}
+	verb := positional[0]
+	rest := positional[1:]
+	if len(rest) == 0 {
+		return verb, ""
+	}
+	switch verb {
+	case "logs", "exec":
+		if strings.Contains(rest[0], "/") {
+			return verb, rest[0]
+		}
+		return verb, "pod/" + rest[0]
+	case "cp":
+		for _, p := range rest {
+			if idx := strings.Index(p, ":"); idx > 0 {
+				return verb, "pod/" + p[:idx]
+			}
+		}
+		return verb, ""
+	}
+	if strings.Contains(rest[0], "/") {
+		return verb, rest[0]
+	}
+	if len(rest) > 1 {
+		return verb, rest[0] + "/" + rest[1]
+	}
+	return verb, rest[0]
+}
+
+// 读取审计日志, 跳过无法解析的行
+func readAuditLog(match func(e AuditEntry) bool) ([]AuditEntry, error) {
+	path, err := auditLogPath()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		if match == nil || match(e) {
+			entries = append(entries, e)
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// 支持相对时间 (24h) 和 RFC3339 时间
+func parseAuditTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("expected a duration like 24h or a time like 2006-01-02T15:04:05Z07:00, got %q", s)
+}
+
+func printAuditTable(entries []AuditEntry) {
+	table := newOutputTable("Time", "User", "Cluster", "Namespace", "Resource", "Action", "Verb", "Result").
+		wide("Context", "Args")
+	for i, e := range entries {
+		result := e.Result
+		if e.Error != "" {
+			result += ": " + e.Error
+		}
+		cluster := e.Cluster
+		if e.Config != "" && e.Config != e.Cluster {
+			cluster = fmt.Sprintf("%s (%s)", e.Cluster, e.Config)
+		}
+		table.append(&entries[i],
+			e.Time.Local().Format("2006-01-02 15:04:05"),
+			e.User,
+			cluster,
+			e.Namespace,
+			e.Resource,
+			e.Action,
+			e.Verb,
+			result,
+			e.Context,
+			strings.Join(e.Args, " "),
+		)
+	}
+	table.render()
+}
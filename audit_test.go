@@ -0,0 +1,23 @@
+package main
+
+import "testing"
+
+func TestKubectlVerbResource(t *testing.T) {
+	tests := []struct {
+		args     []string
+		verb     string
+		resource string
+	}{
+		{[]string{"--kubeconfig", "/tmp/kc", "-n", "prod", "delete", "pod", "api-0"}, "delete", "pod/api-0"},
+		{[]string{"scale", "deployment/api", "--replicas=3"}, "scale", "deployment/api"},
+		{[]string{"logs", "api-0", "-c", "app", "-f"}, "logs", "pod/api-0"},
+		{[]string{"cp", "./local", "api-0:/tmp/x"}, "cp", "pod/api-0"},
+		{[]string{"cp", "./a", "./b"}, "cp", ""},
+	}
+	for _, tt := range tests {
+		verb, resource := kubectlVerbResource(tt.args)
+		if verb != tt.verb || resource != tt.resource {
+			t.Errorf("kubectlVerbResource(%q) = %q, %q, want %q, %q", tt.args, verb, resource, tt.verb, tt.resource)
+		}
+	}
+}
@@ -0,0 +1,395 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// 批量操作同时执行的数量
+const bulkConcurrency = 5
+
+// 批量选择: 3-7, 1,4,9, all (当前搜索结果), status=Evicted (当前搜索结果中状态匹配的)
+var bulkSelectionPattern = regexp.MustCompile(`^\d+(-\d+)?(\s*,\s*\d+(-\d+)?)*$`)
+
+func isBulkSelection(input string) bool {
+	if input == "all" || strings.HasPrefix(input, "status=") {
+		return true
+	}
+	// 单个编号仍然是普通选择
+	return bulkSelectionPattern.MatchString(input) && strings.ContainsAny(input, ",-")
+}
+
+// 按批量选择的语法选出对象, filter 为当前的搜索条件, status 为 nil 时不支持按状态选择
+func selectBulkItems[T any](items []T, input, filter string, name func(T) string, status func(T) []string) ([]T, error) {
+	var selected []T
+	switch {
+	case input == "all":
+		for _, item := range items {
+			if nameContains(name(item), filter) {
+				selected = append(selected, item)
+			}
+		}
+	case strings.HasPrefix(input, "status="):
+		if status == nil {
+			return nil, fmt.Errorf("this list can not be filtered by status")
+		}
+		want := strings.TrimPrefix(input, "status=")
+		for _, item := range items {
+			if !nameContains(name(item), filter) {
+				continue
+			}
+			for _, s := range status(item) {
+				if strings.EqualFold(s, want) {
+					selected = append(selected, item)
+					break
+				}
+			}
+		}
+	default:
+		seen := map[int]bool{}
+		for _, part := range strings.Split(input, ",") {
+			start, end, isRange := strings.Cut(strings.TrimSpace(part), "-")
+			from, _ := strconv.Atoi(start)
+			to := from
+			if isRange {
+				to, _ = strconv.Atoi(end)
+			}
+			if from > to || to >= len(items) {
+				return nil, fmt.Errorf("invalid selection %q, numbers must be between 0 and %d", part, len(items)-1)
+			}
+			for i := from; i <= to; i++ {
+				if !seen[i] {
+					seen[i] = true
+					selected = append(selected, items[i])
+				}
+			}
+		}
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("nothing matches %q", input)
+	}
+	return selected, nil
+}
+
+// pod 的阶段, 原因和容器的等待/退出原因, 例如 Failed, Evicted, CrashLoopBackOff
+func podStatuses(pod v1.Pod) []string {
+	statuses := []string{string(pod.Status.Phase), pod.Status.Reason}
+	for _, s := range pod.Status.ContainerStatuses {
+		if s.State.Waiting != nil {
+			statuses = append(statuses, s.State.Waiting.Reason)
+		}
+		if s.State.Terminated != nil {
+			statuses = append(statuses, s.State.Terminated.Reason)
+		}
+	}
+	return statuses
+}
+
+// deployment 的状态: ready/notready, paused, 为 True 的 condition 类型 (Available, Progressing, ReplicaFailure)
+// 和 condition 的原因, 例如 ProgressDeadlineExceeded, MinimumReplicasUnavailable
+func deploymentStatuses(d appsv1.Deployment) []string {
+	statuses := []string{"notready"}
+	if d.Status.ReadyReplicas == d.Status.Replicas {
+		statuses[0] = "ready"
+	}
+	if d.Spec.Paused {
+		statuses = append(statuses, "paused")
+	}
+	for _, c := range d.Status.Conditions {
+		if c.Status == v1.ConditionTrue {
+			statuses = append(statuses, string(c.Type))
+		}
+		if c.Reason != "" {
+			statuses = append(statuses, c.Reason)
+		}
+	}
+	return statuses
+}
+
+// 批量操作的对象, 使用 UID 作为前置条件, 避免操作同名的新对象
+type bulkTarget struct {
+	ref resourceRef
+	uid types.UID
+	obj interface{}
+}
+
+func newBulkTargets[T any](resource string, items []T) []bulkTarget {
+	var targets []bulkTarget
+	for i := range items {
+		obj := any(&items[i]).(metav1.Object)
+		targets = append(targets, bulkTarget{ref: resourceRefFor(resource, obj.GetName()), uid: obj.GetUID(), obj: &items[i]})
+	}
+	return targets
+}
+
+func resourceRefFor(resource, name string) resourceRef {
+	switch resource {
+	case "pods":
+		return podRef(name)
+	case "deployments":
+		return deploymentRef(name)
+	case "svc":
+		return serviceRef(name)
+	case "configmap":
+		return configMapRef(name)
+	case "pvc":
+		return pvcRef(name)
+	}
+	return pvRef(name)
+}
+
+// 选择批量操作, 确认一次后并发执行
+func handleBulkAction(resource string, targets []bulkTarget) {
+	fmt.Printf("Selected %d %s:\n", len(targets), resource)
+	for _, t := range targets {
+		fmt.Printf("  %s\n", t.ref)
+	}
+	actions := []string{"delete", "label"}
+	switch resource {
+	case "pods":
+		actions = append(actions, "logs to files")
+	case "deployments":
+		actions = append(actions, "restart", "scale")
+	}
+	actions = append(actions, "exit")
+	action := ""
+	if err := survey.AskOne(&survey.Select{Message: "choose bulk action:", Options: actions}, &action); err != nil || action == "exit" {
+		return
+	}
+
+	var run func(ctx context.Context, t bulkTarget) (string, error)
+	switch action {
+	case "delete":
+		run = bulkDelete
+	case "label":
+		labels, err := promptLabelChanges()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		run = func(ctx context.Context, t bulkTarget) (string, error) {
+			return "labeled", bulkPatch(ctx, t, "label", map[string]interface{}{"metadata": map[string]interface{}{"labels": labels}})
+		}
+	case "restart":
+		// 与 kubectl rollout restart 相同, 修改 pod 模板的注解触发滚动更新
+		restartedAt := time.Now().Format(time.RFC3339)
+		run = func(ctx context.Context, t bulkTarget) (string, error) {
+			patch := map[string]interface{}{"spec": map[string]interface{}{"template": map[string]interface{}{
+				"metadata": map[string]interface{}{"annotations": map[string]interface{}{"kubectl.kubernetes.io/restartedAt": restartedAt}},
+			}}}
+			return "restarted", bulkPatch(ctx, t, "restart", patch)
+		}
+	case "scale":
+		replicas := ""
+		survey.AskOne(&survey.Input{Message: "Enter the number of replicas: "}, &replicas, survey.WithValidator(func(ans interface{}) error {
+			if n, err := strconv.Atoi(ans.(string)); err != nil || n < 0 {
+				return fmt.Errorf("please enter a non-negative number")
+			}
+			return nil
+		}))
+		n, err := strconv.Atoi(replicas)
+		if err != nil {
+			return
+		}
+		run = func(ctx context.Context, t bulkTarget) (string, error) {
+			return fmt.Sprintf("scaled to %d", n), bulkPatch(ctx, t, "scale", map[string]interface{}{"spec": map[string]interface{}{"replicas": n}})
+		}
+	case "logs to files":
+		dir := fmt.Sprintf("kube-ui-logs-%s", time.Now().Format("20060102-150405"))
+		survey.AskOne(&survey.Input{Message: "Directory to save logs in: ", Default: dir}, &dir)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			fmt.Printf("Error creating directory %s: %v\n", dir, err)
+			return
+		}
+		run = func(ctx context.Context, t bulkTarget) (string, error) {
+			return bulkSaveLogs(ctx, t, dir)
+		}
+	}
+
+	confirm := false
+	survey.AskOne(&survey.Confirm{
+		Message: fmt.Sprintf("%s %d %s?", action, len(targets), resource),
+		Default: false,
+	}, &confirm)
+	if !confirm {
+		return
+	}
+	runBulk(targets, run)
+}
+
+// 并发执行并输出进度, Ctrl+C 取消还没有完成的操作, 最后输出结果汇总
+func runBulk(targets []bulkTarget, run func(ctx context.Context, t bulkTarget) (string, error)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt)
+	defer signal.Stop(sigChan)
+	go func() {
+		select {
+		case <-sigChan:
+			fmt.Println("\nInterrupted, cancelling the remaining operations...")
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	var mu sync.Mutex
+	var failed []string
+	done := 0
+	sem := make(chan struct{}, bulkConcurrency)
+	var wg sync.WaitGroup
+	for _, t := range targets {
+		wg.Add(1)
+		go func(t bulkTarget) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			var result string
+			err := ctx.Err()
+			if err == nil {
+				result, err = run(ctx, t)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			done++
+			if err != nil {
+				failed = append(failed, fmt.Sprintf("%s: %v", t.ref, err))
+				fmt.Printf("[%d/%d] \033[1;31m✗ %s: %v\033[0m\n", done, len(targets), t.ref, err)
+				return
+			}
+			fmt.Printf("[%d/%d] \033[1;32m✓ %s %s\033[0m\n", done, len(targets), t.ref, result)
+		}(t)
+	}
+	wg.Wait()
+
+	fmt.Println("====================================")
+	fmt.Printf("%d succeeded, %d failed\n", len(targets)-len(failed), len(failed))
+	for _, f := range failed {
+		fmt.Printf("  \033[1;31m%s\033[0m\n", f)
+	}
+}
+
+func bulkDelete(ctx context.Context, t bulkTarget) (string, error) {
+	if _, err := backupObject(t.ref, "delete"); err != nil {
+		return "", fmt.Errorf("backup failed, not deleted: %v", err)
+	}
+	return deleteBulkTarget(ctx, t, "bulk-delete")
+}
+
+func deleteBulkTarget(ctx context.Context, t bulkTarget, operation string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout())
+	defer cancel()
+	err := t.ref.client().Delete(ctx, t.ref.Name, metav1.DeleteOptions{Preconditions: metav1.NewUIDPreconditions(string(t.uid))})
+	auditAPI(operation, "delete", t.ref.String(), err)
+	if err == nil {
+		expectInCache(t.ref, nil)
+	}
+	return "deleted", err
+}
+
+// merge patch 中带上 uid, 对象被替换时 API server 拒绝修改
+func bulkPatch(ctx context.Context, t bulkTarget, operation string, patch map[string]interface{}) error {
+	if _, err := backupObject(t.ref, operation); err != nil {
+		return fmt.Errorf("backup failed, not changed: %v", err)
+	}
+	metadata, _ := patch["metadata"].(map[string]interface{})
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+		patch["metadata"] = metadata
+	}
+	metadata["uid"] = string(t.uid)
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout())
+	defer cancel()
+	patched, err := t.ref.client().Patch(ctx, t.ref.Name, types.MergePatchType, data, metav1.PatchOptions{FieldManager: fieldManager})
+	auditAPI("bulk-"+operation, "patch", t.ref.String(), err)
+	if err == nil {
+		expectInCache(t.ref, patched)
+	}
+	return err
+}
+
+func promptLabelChanges() (map[string]interface{}, error) {
+	input := ""
+	survey.AskOne(&survey.Input{Message: "Enter labels, e.g. team=web,old-label- : "}, &input)
+	return parseLabelChanges(input)
+}
+
+// 解析 "app=web,tier-": key=value 添加或修改, key- 删除
+func parseLabelChanges(input string) (map[string]interface{}, error) {
+	labels := map[string]interface{}{}
+	for _, part := range strings.FieldsFunc(input, func(r rune) bool { return r == ',' || r == ' ' }) {
+		if key, ok := strings.CutSuffix(part, "-"); ok && !strings.Contains(part, "=") {
+			if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+				return nil, fmt.Errorf("invalid label key %q: %s", key, strings.Join(errs, "; "))
+			}
+			labels[key] = nil
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid label %q, expected key=value or key-", part)
+		}
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid label key %q: %s", key, strings.Join(errs, "; "))
+		}
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid label value %q: %s", value, strings.Join(errs, "; "))
+		}
+		labels[key] = value
+	}
+	if len(labels) == 0 {
+		return nil, fmt.Errorf("no labels entered")
+	}
+	return labels, nil
+}
+
+// 每个容器的日志保存为 <pod>_<container>.log, 日志可能很大, 不设置超时
+func bulkSaveLogs(ctx context.Context, t bulkTarget, dir string) (string, error) {
+	pod := t.obj.(*v1.Pod)
+	var files []string
+	for _, c := range pod.Spec.Containers {
+		path := filepath.Join(dir, fmt.Sprintf("%s_%s.log", pod.Name, c.Name))
+		if err := savePodLogs(ctx, pod.Name, c.Name, path); err != nil {
+			return "", fmt.Errorf("container %s: %v", c.Name, err)
+		}
+		files = append(files, path)
+	}
+	return "logs saved to " + strings.Join(files, ", "), nil
+}
+
+func savePodLogs(ctx context.Context, name, container, path string) error {
+	stream, err := k8sClient.CoreV1().Pods(*namespace).GetLogs(name, &v1.PodLogOptions{Container: container}).Stream(ctx)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(f, stream)
+	return err
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSelectBulkItems(t *testing.T) {
+	type item struct {
+		name   string
+		status string
+	}
+	items := []item{{"api-0", "Running"}, {"api-1", "Evicted"}, {"worker-0", "Evicted"}, {"worker-1", "Running"}}
+	name := func(i item) string { return i.name }
+	status := func(i item) []string { return []string{i.status} }
+	tests := []struct {
+		input   string
+		filter  string
+		status  func(item) []string
+		want    []string
+		wantErr bool
+	}{
+		{input: "all", want: []string{"api-0", "api-1", "worker-0", "worker-1"}},
+		{input: "all", filter: "worker", want: []string{"worker-0", "worker-1"}},
+		{input: "all", filter: "db", wantErr: true},
+		{input: "status=evicted", status: status, want: []string{"api-1", "worker-0"}},
+		{input: "status=Evicted", filter: "worker", status: status, want: []string{"worker-0"}},
+		{input: "status=Evicted", wantErr: true},
+		{input: "status=Pending", status: status, wantErr: true},
+		{input: "1,3", want: []string{"api-1", "worker-1"}},
+		{input: "0-2", want: []string{"api-0", "api-1", "worker-0"}},
+		{input: "2, 1-2", want: []string{"worker-0", "api-1"}},
+		{input: "2-1", wantErr: true},
+		{input: "1-4", wantErr: true},
+	}
+	for _, tt := range tests {
+		selected, err := selectBulkItems(items, tt.input, tt.filter, name, tt.status)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("selectBulkItems(%q, %q) error = %v, wantErr %v", tt.input, tt.filter, err, tt.wantErr)
+			continue
+		}
+		var got []string
+		for _, i := range selected {
+			got = append(got, i.name)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("selectBulkItems(%q, %q) = %v, want %v", tt.input, tt.filter, got, tt.want)
+		}
+	}
+}
+
+func TestParseLabelChanges(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{input: "team=web", want: map[string]interface{}{"team": "web"}},
+		{input: "team=web, old-", want: map[string]interface{}{"team": "web", "old": nil}},
+		{input: "app.kubernetes.io/name=api,tier-", want: map[string]interface{}{"app.kubernetes.io/name": "api", "tier": nil}},
+		{input: "team=", want: map[string]interface{}{"team": ""}},
+		{input: "name=a-", wantErr: true},
+		{input: "", wantErr: true},
+		{input: "team", wantErr: true},
+		{input: "bad key=web", wantErr: true},
+		{input: "team=has space", wantErr: true},
+		{input: "-=x", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseLabelChanges(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseLabelChanges(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseLabelChanges(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+// 已经结束的 pod 的原因: Evicted, OOMKilled, Completed, Error, ContainerStatusUnknown 等, 运行中的 pod 返回空
+func terminalPodReason(pod *v1.Pod) string {
+	if pod.Status.Phase != v1.PodSucceeded && pod.Status.Phase != v1.PodFailed {
+		return ""
+	}
+	if pod.Status.Reason != "" {
+		return pod.Status.Reason
+	}
+	for _, s := range pod.Status.ContainerStatuses {
+		if s.State.Terminated != nil && s.State.Terminated.Reason != "" {
+			return s.State.Terminated.Reason
+		}
+	}
+	if pod.Status.Phase == v1.PodSucceeded {
+		return "Completed"
+	}
+	return string(pod.Status.Phase)
+}
+
+// 表格中显示的状态, 结束的 pod 带上原因, 例如 Failed (Evicted)
+func podDisplayStatus(pod *v1.Pod) string {
+	if reason := terminalPodReason(pod); reason != "" && reason != string(pod.Status.Phase) {
+		return fmt.Sprintf("%s (%s)", pod.Status.Phase, reason)
+	}
+	return string(pod.Status.Phase)
+}
+
+// 解析 30m, 12h, 7d 这样的时间
+func parseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age %q, expected e.g. 30m, 12h or 7d", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q, expected e.g. 30m, 12h or 7d", s)
+	}
+	return d, nil
+}
+
+// pod 结束的时间: 容器最晚的退出时间, 没有时使用最晚的 condition 变化时间, 最后使用创建时间
+func podFinishedAt(pod *v1.Pod) time.Time {
+	var finished time.Time
+	for _, statuses := range [][]v1.ContainerStatus{pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses} {
+		for _, s := range statuses {
+			if s.State.Terminated != nil && s.State.Terminated.FinishedAt.After(finished) {
+				finished = s.State.Terminated.FinishedAt.Time
+			}
+		}
+	}
+	if !finished.IsZero() {
+		return finished
+	}
+	for _, c := range pod.Status.Conditions {
+		if c.LastTransitionTime.After(finished) {
+			finished = c.LastTransitionTime.Time
+		}
+	}
+	if !finished.IsZero() {
+		return finished
+	}
+	return pod.CreationTimestamp.Time
+}
+
+type podCleanupGroup struct {
+	reason string
+	pods   []v1.Pod
+	oldest time.Time
+	newest time.Time
+}
+
+// 按原因分组结束的 pod, 只包含结束时间超过 minAge 的 pod, 数量多的组在前
+func groupTerminalPods(pods []v1.Pod, minAge time.Duration) []*podCleanupGroup {
+	groups := map[string]*podCleanupGroup{}
+	for i := range pods {
+		pod := &pods[i]
+		reason := terminalPodReason(pod)
+		if reason == "" {
+			continue
+		}
+		finished := podFinishedAt(pod)
+		if time.Since(finished) < minAge {
+			continue
+		}
+		g, ok := groups[reason]
+		if !ok {
+			g = &podCleanupGroup{reason: reason, oldest: finished, newest: finished}
+			groups[reason] = g
+		}
+		g.pods = append(g.pods, *pod)
+		if finished.Before(g.oldest) {
+			g.oldest = finished
+		}
+		if finished.After(g.newest) {
+			g.newest = finished
+		}
+	}
+	var result []*podCleanupGroup
+	for _, g := range groups {
+		result = append(result, g)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if len(result[i].pods) != len(result[j].pods) {
+			return len(result[i].pods) > len(result[j].pods)
+		}
+		return result[i].reason < result[j].reason
+	})
+	return result
+}
+
+// 清理已经结束的 pod: 按原因分组显示数量和年龄, 选择要删除的组
+func handlePodCleanupAction() {
+	pods, err := cachedList[v1.Pod]("pods")
+	if err != nil {
+		fmt.Printf("Error listing pods: %v\n", err)
+		return
+	}
+
+	ageInput := ""
+	survey.AskOne(&survey.Input{Message: "Only pods finished longer ago than (e.g. 1h, 7d, empty for all): "}, &ageInput, survey.WithValidator(func(ans interface{}) error {
+		if s := strings.TrimSpace(ans.(string)); s != "" {
+			_, err := parseAge(s)
+			return err
+		}
+		return nil
+	}))
+	var minAge time.Duration
+	if s := strings.TrimSpace(ageInput); s != "" {
+		minAge, _ = parseAge(s)
+	}
+
+	groups := groupTerminalPods(pods, minAge)
+	if len(groups) == 0 {
+		fmt.Printf("No finished pods to clean up in namespace %s\n", *namespace)
+		return
+	}
+	table := newOutputTable("Reason", "Count", "Oldest Finished", "Newest Finished")
+	var options []string
+	for _, g := range groups {
+		table.append(nil, g.reason, strconv.Itoa(len(g.pods)),
+			duration.HumanDuration(time.Since(g.oldest)), duration.HumanDuration(time.Since(g.newest)))
+		options = append(options, fmt.Sprintf("%s (%d)", g.reason, len(g.pods)))
+	}
+	table.render()
+
+	var selected []int
+	if err := survey.AskOne(&survey.MultiSelect{
+		Message: "choose the groups to delete:",
+		Options: options,
+	}, &selected); err != nil || len(selected) == 0 {
+		return
+	}
+	var items []v1.Pod
+	var names []string
+	for _, i := range selected {
+		items = append(items, groups[i].pods...)
+		names = append(names, options[i])
+	}
+
+	// 默认和批量删除一样逐个备份, pod 很多时可以明确选择不备份
+	skipBackup := false
+	survey.AskOne(&survey.Confirm{
+		Message: fmt.Sprintf("Skip the snapshots of the %d pods? Skipped pods can not be restored", len(items)),
+		Default: false,
+	}, &skipBackup)
+	message := fmt.Sprintf("Delete %d pods (%s)?", len(items), strings.Join(names, ", "))
+	run := cleanupDelete
+	if skipBackup {
+		message += " No snapshots are saved"
+		run = cleanupDeleteNoBackup
+	}
+
+	confirm := false
+	survey.AskOne(&survey.Confirm{
+		Message: message,
+		Default: false,
+	}, &confirm)
+	if !confirm {
+		return
+	}
+	runBulk(newBulkTargets("pods", items), run)
+}
+
+func cleanupDelete(ctx context.Context, t bulkTarget) (string, error) {
+	if _, err := backupObject(t.ref, "delete"); err != nil {
+		return "", fmt.Errorf("backup failed, not deleted: %v", err)
+	}
+	return deleteBulkTarget(ctx, t, "cleanup-delete")
+}
+
+// 选择不备份时审计日志中记录为 cleanup-delete-no-backup, 仍然使用 UID 前置条件
+func cleanupDeleteNoBackup(ctx context.Context, t bulkTarget) (string, error) {
+	return deleteBulkTarget(ctx, t, "cleanup-delete-no-backup")
+}
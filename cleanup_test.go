@@ -0,0 +1,82 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{input: "30m", want: 30 * time.Minute},
+		{input: "12h", want: 12 * time.Hour},
+		{input: "7d", want: 7 * 24 * time.Hour},
+		{input: "-1d", wantErr: true},
+		{input: "1.5d", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseAge(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseAge(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseAge(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestGroupTerminalPods(t *testing.T) {
+	now := time.Now()
+	pod := func(name string, phase v1.PodPhase, reason string, finishedAgo time.Duration) v1.Pod {
+		return v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: metav1.NewTime(now.Add(-30 * 24 * time.Hour))},
+			Status: v1.PodStatus{Phase: phase, Reason: reason, ContainerStatuses: []v1.ContainerStatus{
+				{State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{FinishedAt: metav1.NewTime(now.Add(-finishedAgo))}}},
+			}},
+		}
+	}
+	pods := []v1.Pod{
+		pod("job-1", v1.PodSucceeded, "", 2*time.Hour),
+		pod("api-1", v1.PodFailed, "Evicted", 3*time.Hour),
+		pod("api-2", v1.PodFailed, "Evicted", 10*time.Minute),
+		pod("api-3", v1.PodRunning, "", time.Hour),
+		pod("api-4", v1.PodFailed, "Evicted", 5*time.Hour),
+	}
+	type group struct {
+		reason string
+		pods   []string
+	}
+	tests := []struct {
+		minAge time.Duration
+		want   []group
+	}{
+		{0, []group{{"Evicted", []string{"api-1", "api-2", "api-4"}}, {"Completed", []string{"job-1"}}}},
+		{4 * time.Hour, []group{{"Evicted", []string{"api-4"}}}},
+	}
+	for _, tt := range tests {
+		var got []group
+		for _, g := range groupTerminalPods(pods, tt.minAge) {
+			var names []string
+			for _, p := range g.pods {
+				names = append(names, p.Name)
+			}
+			got = append(got, group{g.reason, names})
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("groupTerminalPods(%v) = %v, want %v", tt.minAge, got, tt.want)
+		}
+	}
+
+	groups := groupTerminalPods(pods, 0)
+	if oldest, newest := now.Sub(groups[0].oldest).Round(time.Minute), now.Sub(groups[0].newest).Round(time.Minute); oldest != 5*time.Hour || newest != 10*time.Minute {
+		t.Errorf("Evicted group ages = %v, %v, want 5h, 10m", oldest, newest)
+	}
+}
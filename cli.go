@@ -0,0 +1,362 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// 与交互菜单对应的非交互子命令, 例如 kube-ui pods logs <name> -F
+
+var (
+	logsFollow     *bool
+	logsContainer  *string
+	shellContainer *string
+	tunnelLocal    *int
+	proxyLocal     *int
+	reversePort    *int
+	applyYes       *bool
+)
+
+// 子命令的公共处理: 创建客户端, 出错时以非 0 状态码退出
+func cliRun(fn func(args []string) error) func(cmd *cobra.Command, args []string) {
+	return func(cmd *cobra.Command, args []string) {
+		err := initKubeClient()
+		if err == nil {
+			// 子命令不询问命名空间
+			if *namespace == "" {
+				*namespace = "default"
+			}
+			err = fn(args)
+		}
+		line.Close()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+}
+
+// 每种资源都有的 list/get/describe/edit/restore 子命令
+type cliResource struct {
+	use     string
+	aliases []string
+	plural  string // 帮助信息中使用的名称
+	kind    string // kubectl 使用的资源名
+	ref     func(name string) resourceRef
+	list    func(filter string) error
+	sortKey string // 排序配置中使用的资源名
+}
+
+func newResourceCmd(r cliResource) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     r.use,
+		Aliases: r.aliases,
+		Short:   fmt.Sprintf("Manage %s", r.plural),
+	}
+	listCmd := &cobra.Command{
+		Use:   "list [filter]",
+		Short: fmt.Sprintf("List %s, optionally only names containing filter", r.plural),
+		Args:  cobra.MaximumNArgs(1),
+	}
+	sortBy := listCmd.Flags().String("sort", "", "sort by column, prefix with - for descending order, e.g. -age")
+	listCmd.Run = cliRun(func(args []string) error {
+		filter := ""
+		if len(args) == 1 {
+			filter = args[0]
+		}
+		if *sortBy != "" {
+			listSorts[r.sortKey] = *sortBy
+		}
+		return r.list(filter)
+	})
+	cmd.AddCommand(
+		listCmd,
+		&cobra.Command{
+			Use:   "get <name>",
+			Short: fmt.Sprintf("Print a %s as yaml", r.kind),
+			Args:  cobra.ExactArgs(1),
+			Run: cliRun(func(args []string) error {
+				return execCommand("get", r.kind, args[0], "-o", "yaml")
+			}),
+		},
+		&cobra.Command{
+			Use:   "describe <name>",
+			Short: fmt.Sprintf("Describe a %s", r.kind),
+			Args:  cobra.ExactArgs(1),
+			Run: cliRun(func(args []string) error {
+				return execCommand("describe", r.kind, args[0])
+			}),
+		},
+		&cobra.Command{
+			Use:   "edit <name>",
+			Short: fmt.Sprintf("Edit a %s in $EDITOR with validation and diff", r.kind),
+			Args:  cobra.ExactArgs(1),
+			Run: cliRun(func(args []string) error {
+				return editResource(r.ref(args[0]))
+			}),
+		},
+		&cobra.Command{
+			Use:   "restore <name>",
+			Short: fmt.Sprintf("Restore a %s from a local snapshot", r.kind),
+			Args:  cobra.ExactArgs(1),
+			Run: cliRun(func(args []string) error {
+				return restoreResource(r.ref(args[0]))
+			}),
+		},
+	)
+	return cmd
+}
+
+func nameContains(name, filter string) bool {
+	return strings.Contains(name, filter)
+}
+
+// 子命令和交互模式一样从 informer 缓存读取, 首次加载分页获取; --sort 无效时返回错误
+func cliList[T any](resource string) ([]T, error) {
+	if spec := listSort(resource); spec != "" {
+		if _, _, err := findSortColumn[T](resource, spec); err != nil {
+			return nil, err
+		}
+	}
+	items, err := cachedList[T](resource)
+	if err != nil {
+		return nil, fmt.Errorf("Error listing %s: %v", resource, err)
+	}
+	return items, nil
+}
+
+func listPods(filter string) error {
+	pods, err := cliList[v1.Pod]("pods")
+	if err != nil {
+		return err
+	}
+	printPodTable(&v1.PodList{Items: pods}, filter, func(pod v1.Pod, input string) bool {
+		return nameContains(pod.Name, input)
+	})
+	return nil
+}
+
+func listDeployments(filter string) error {
+	deployments, err := cliList[appsv1.Deployment]("deployments")
+	if err != nil {
+		return err
+	}
+	printDeploymentTable(&appsv1.DeploymentList{Items: deployments}, filter, func(deployment appsv1.Deployment, input string) bool {
+		return nameContains(deployment.Name, input)
+	})
+	return nil
+}
+
+func listServices(filter string) error {
+	services, err := cliList[v1.Service]("svc")
+	if err != nil {
+		return err
+	}
+	printSvcTable(&v1.ServiceList{Items: services}, filter, func(svc v1.Service, input string) bool {
+		return nameContains(svc.Name, input)
+	})
+	return nil
+}
+
+func listPvcs(filter string) error {
+	pvcs, err := cliList[v1.PersistentVolumeClaim]("pvc")
+	if err != nil {
+		return err
+	}
+	printPvcTable(&v1.PersistentVolumeClaimList{Items: pvcs}, filter, func(pvc v1.PersistentVolumeClaim, input string) bool {
+		return nameContains(pvc.Name, input)
+	})
+	return nil
+}
+
+func listPvs(filter string) error {
+	pvs, err := cliList[v1.PersistentVolume]("pv")
+	if err != nil {
+		return err
+	}
+	printPvTable(&v1.PersistentVolumeList{Items: pvs}, filter, func(pv v1.PersistentVolume, input string) bool {
+		return nameContains(pv.Name, input)
+	})
+	return nil
+}
+
+func listConfigMaps(filter string) error {
+	configMaps, err := cliList[v1.ConfigMap]("configmap")
+	if err != nil {
+		return err
+	}
+	printConfigMapTable(&v1.ConfigMapList{Items: configMaps}, filter, func(cm v1.ConfigMap, input string) bool {
+		return nameContains(cm.Name, input)
+	})
+	return nil
+}
+
+func init() {
+	podsCmd := newResourceCmd(cliResource{use: "pods", aliases: []string{"pod", "po"}, plural: "pods", kind: "pod", ref: podRef, list: listPods, sortKey: "pods"})
+	logsCmd := &cobra.Command{
+		Use:   "logs <name>",
+		Short: "Print the logs of a pod",
+		Args:  cobra.ExactArgs(1),
+		Run: cliRun(func(args []string) error {
+			return podLogs(args[0], *logsContainer, *logsFollow)
+		}),
+	}
+	logsFollow = logsCmd.Flags().BoolP("follow", "F", false, "stream the logs, starting from the last 1000 lines")
+	logsContainer = logsCmd.Flags().StringP("container", "c", "", "container name")
+	shellCmd := &cobra.Command{
+		Use:   "exec <name>",
+		Short: "Open a shell in a pod",
+		Args:  cobra.ExactArgs(1),
+		Run: cliRun(func(args []string) error {
+			return podShell(args[0], *shellContainer)
+		}),
+	}
+	shellContainer = shellCmd.Flags().StringP("container", "c", "", "container name")
+	podsCmd.AddCommand(
+		logsCmd,
+		shellCmd,
+		&cobra.Command{
+			Use:   "events <name>",
+			Short: "Print the events of a pod",
+			Args:  cobra.ExactArgs(1),
+			Run: cliRun(func(args []string) error {
+				printPodEvents(v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: args[0]}})
+				return nil
+			}),
+		},
+		&cobra.Command{
+			Use:   "delete <name>",
+			Short: "Delete a pod after saving a local snapshot",
+			Args:  cobra.ExactArgs(1),
+			Run: cliRun(func(args []string) error {
+				return deletePod(args[0], "")
+			}),
+		},
+		&cobra.Command{
+			Use:   "forward <name> <localPort:podPort>...",
+			Short: "Forward local ports to a pod",
+			Args:  cobra.MinimumNArgs(2),
+			Run: cliRun(func(args []string) error {
+				return forwardPorts("pod/"+args[0], args[1:])
+			}),
+		},
+		&cobra.Command{
+			Use:   "cp <src> <dst>",
+			Short: "Copy files from or to a pod, use <pod>:<path> for the remote side",
+			Args:  cobra.ExactArgs(2),
+			Run: cliRun(func(args []string) error {
+				return execCommand("cp", args[0], args[1])
+			}),
+		},
+	)
+
+	deployCmd := newResourceCmd(cliResource{use: "deploy", aliases: []string{"deployment", "deployments"}, plural: "deployments", kind: "deployment", ref: deploymentRef, list: listDeployments, sortKey: "deployments"})
+	deployCmd.AddCommand(&cobra.Command{
+		Use:   "scale <name> <replicas>",
+		Short: "Scale a deployment after saving a local snapshot",
+		Args:  cobra.ExactArgs(2),
+		Run: cliRun(func(args []string) error {
+			replicas, err := strconv.Atoi(args[1])
+			if err != nil || replicas < 0 {
+				return fmt.Errorf("invalid number of replicas %q", args[1])
+			}
+			return scaleDeployment(args[0], replicas)
+		}),
+	})
+
+	svcCmd := newResourceCmd(cliResource{use: "svc", aliases: []string{"service", "services"}, plural: "services", kind: "svc", ref: serviceRef, list: listServices, sortKey: "svc"})
+	svcCmd.AddCommand(&cobra.Command{
+		Use:   "forward <name> <localPort:svcPort>...",
+		Short: "Forward local ports to a service",
+		Args:  cobra.MinimumNArgs(2),
+		Run: cliRun(func(args []string) error {
+			return forwardPorts("svc/"+args[0], args[1:])
+		}),
+	})
+
+	configMapCmd := newResourceCmd(cliResource{use: "configmap", aliases: []string{"configmaps", "cm"}, plural: "configmaps", kind: "configmap", ref: configMapRef, list: listConfigMaps, sortKey: "configmap"})
+	applyCmd := &cobra.Command{
+		Use:   "apply <name> <file>",
+		Short: "Apply a local yaml file to a configmap with diff and confirmation",
+		Args:  cobra.ExactArgs(2),
+		Run: cliRun(func(args []string) error {
+			ref := configMapRef(args[0])
+			return applyFile(args[1], &ref, *applyYes)
+		}),
+	}
+	applyYes = applyCmd.Flags().BoolP("yes", "y", false, "apply without confirmation, only the named configmap is applied and field conflicts are not forced")
+	configMapCmd.AddCommand(applyCmd)
+
+	rootCmd.AddCommand(
+		podsCmd,
+		deployCmd,
+		svcCmd,
+		configMapCmd,
+		newResourceCmd(cliResource{use: "pvc", aliases: []string{"pvcs"}, plural: "persistent volume claims", kind: "pvc", ref: pvcRef, list: listPvcs, sortKey: "pvc"}),
+		newResourceCmd(cliResource{use: "pv", aliases: []string{"pvs"}, plural: "persistent volumes", kind: "pv", ref: pvRef, list: listPvs, sortKey: "pv"}),
+	)
+
+	// tunnel 的子命令, 与 tunnel 菜单中的模式对应
+	startCmd := &cobra.Command{
+		Use:   "start <target>...",
+		Short: "Forward host:port targets through a tunnel pod",
+		Long:  `Forward targets through a tunnel pod, e.g. "kube-ui tunnel start db.internal:5432 --local 5432" or "kube-ui tunnel start 5432=db:5432 53=udp://kube-dns.kube-system:53"`,
+		Args:  cobra.MinimumNArgs(1),
+		Run: cliRun(func(args []string) error {
+			mappings, err := parseTunnelMappings(strings.Join(args, " "))
+			if err != nil {
+				return err
+			}
+			if len(mappings) == 1 && mappings[0].LocalPort == 0 {
+				mappings[0].LocalPort = mappings[0].Port
+				if *tunnelLocal != 0 {
+					mappings[0].LocalPort = *tunnelLocal
+				}
+			}
+			runTunnelSession(mappings)
+			return nil
+		}),
+	}
+	tunnelLocal = startCmd.Flags().Int("local", 0, "local port for a single target, defaults to the target port")
+	proxyCmd := &cobra.Command{
+		Use:   "proxy",
+		Short: "Start a SOCKS5/HTTP CONNECT proxy into the cluster",
+		Args:  cobra.NoArgs,
+		Run: cliRun(func(args []string) error {
+			if _, err := parsePort(strconv.Itoa(*proxyLocal)); err != nil {
+				return err
+			}
+			runProxyTunnel(*proxyLocal)
+			return nil
+		}),
+	}
+	proxyLocal = proxyCmd.Flags().Int("local", proxyPort, "local proxy port")
+	reverseCmd := &cobra.Command{
+		Use:   "reverse <localPort>",
+		Short: "Expose a local port to the cluster through a Service",
+		Args:  cobra.ExactArgs(1),
+		Run: cliRun(func(args []string) error {
+			localPort, err := parsePort(args[0])
+			if err != nil {
+				return err
+			}
+			servicePort := localPort
+			if *reversePort != 0 {
+				if servicePort, err = parsePort(strconv.Itoa(*reversePort)); err != nil {
+					return err
+				}
+			}
+			runReverseTunnel(localPort, servicePort)
+			return nil
+		}),
+	}
+	reversePort = reverseCmd.Flags().Int("port", 0, "service port, defaults to the local port")
+	tunnelCmd.AddCommand(startCmd, proxyCmd, reverseCmd)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// 所有命令的本地 flag 和继承的 flag 不能冲突, 冲突时 cobra 在解析参数时 panic
+func TestCommandFlagsDoNotConflict(t *testing.T) {
+	var walk func(cmd *cobra.Command)
+	walk = func(cmd *cobra.Command) {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("flags of %q conflict: %v", cmd.CommandPath(), r)
+				}
+			}()
+			cmd.InheritedFlags()
+			cmd.LocalFlags()
+		}()
+		for _, c := range cmd.Commands() {
+			walk(c)
+		}
+	}
+	walk(rootCmd)
+}
+
+func TestFindCommand(t *testing.T) {
+	tests := []struct {
+		args     []string
+		wantPath string
+		wantArgs []string
+	}{
+		{[]string{"pods", "list", "api"}, "kube-ui pods list", []string{"api"}},
+		{[]string{"po", "logs", "api-0"}, "kube-ui pods logs", []string{"api-0"}},
+		{[]string{"deployment", "scale", "web", "3"}, "kube-ui deploy scale", []string{"web", "3"}},
+		{[]string{"cm", "apply", "settings", "cm.yaml"}, "kube-ui configmap apply", []string{"settings", "cm.yaml"}},
+		{[]string{"services", "forward", "web", "8080:80"}, "kube-ui svc forward", []string{"web", "8080:80"}},
+		{[]string{"pvs", "restore", "data"}, "kube-ui pv restore", []string{"data"}},
+		{[]string{"tunnel", "start", "db:5432"}, "kube-ui tunnel start", []string{"db:5432"}},
+		{[]string{"tunnel", "reverse", "8080"}, "kube-ui tunnel reverse", []string{"8080"}},
+	}
+	for _, tt := range tests {
+		cmd, args, err := rootCmd.Find(tt.args)
+		if err != nil {
+			t.Errorf("Find(%v) error = %v", tt.args, err)
+			continue
+		}
+		if cmd.CommandPath() != tt.wantPath || len(args) != len(tt.wantArgs) {
+			t.Errorf("Find(%v) = %q %v, want %q %v", tt.args, cmd.CommandPath(), args, tt.wantPath, tt.wantArgs)
+			continue
+		}
+		if err := cmd.ValidateArgs(args); err != nil {
+			t.Errorf("Find(%v) args %v are not valid: %v", tt.args, args, err)
+		}
+	}
+}
+
+func TestCommandShorthands(t *testing.T) {
+	tests := []struct {
+		path      []string
+		shorthand string
+		wantFlag  string
+	}{
+		{[]string{"pods", "logs"}, "F", "follow"},
+		{[]string{"pods", "logs"}, "c", "container"},
+		{[]string{"pods", "logs"}, "f", "kubeconfig"},
+		{[]string{"pods", "logs"}, "n", "namespace"},
+		{[]string{"pods", "exec"}, "c", "container"},
+		{[]string{"configmap", "apply"}, "y", "yes"},
+		{[]string{}, "f", "kubeconfig"},
+	}
+	for _, tt := range tests {
+		cmd, _, err := rootCmd.Find(tt.path)
+		if err != nil {
+			t.Fatalf("Find(%v) error = %v", tt.path, err)
+		}
+		flag := cmd.Flags().ShorthandLookup(tt.shorthand)
+		if flag == nil {
+			flag = cmd.InheritedFlags().ShorthandLookup(tt.shorthand)
+		}
+		if flag == nil || flag.Name != tt.wantFlag {
+			t.Errorf("%q -%s = %v, want --%s", cmd.CommandPath(), tt.shorthand, flag, tt.wantFlag)
+		}
+	}
+}
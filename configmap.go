@@ -0,0 +1,429 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/peterh/liner"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// ConfigMap 中的一个 key, binaryData 中的 key 标记为 Binary
+type configMapKey struct {
+	Name   string
+	Binary bool
+	Size   int
+}
+
+func configMapKeys(cm *v1.ConfigMap) []configMapKey {
+	var keys []configMapKey
+	for k, v := range cm.Data {
+		keys = append(keys, configMapKey{Name: k, Size: len(v)})
+	}
+	for k, v := range cm.BinaryData {
+		keys = append(keys, configMapKey{Name: k, Binary: true, Size: len(v)})
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].Name < keys[j].Name
+	})
+	return keys
+}
+
+func configMapSize(cm v1.ConfigMap) int {
+	size := 0
+	for _, v := range cm.Data {
+		size += len(v)
+	}
+	for _, v := range cm.BinaryData {
+		size += len(v)
+	}
+	return size
+}
+
+// 格式化字节数
+func formatBytes(n int) string {
+	switch {
+	case n >= 1024*1024:
+		return fmt.Sprintf("%.1fMi", float64(n)/1024/1024)
+	case n >= 1024:
+		return fmt.Sprintf("%.1fKi", float64(n)/1024)
+	}
+	return fmt.Sprintf("%dB", n)
+}
+
+func printConfigMapKeyTable(keys []configMapKey) {
+	table := newOutputTable("Number", "Key", "Type", "Size")
+	for i, k := range keys {
+		keyType := "data"
+		if k.Binary {
+			keyType = "binaryData"
+		}
+		table.append(nil, fmt.Sprintf("%d", i), k.Name, keyType, formatBytes(k.Size))
+	}
+	table.render()
+}
+
+// ConfigMap key 级别的查看和编辑
+func handleConfigMapKeysAction(line *liner.State, name string) {
+	for {
+		ctx, cancel := requestContext()
+		cm, err := k8sClient.CoreV1().ConfigMaps(*namespace).Get(ctx, name, metav1.GetOptions{})
+		err = requestError(ctx, err)
+		cancel()
+		if err != nil {
+			fmt.Printf("Error getting configmap %s: %v\n", name, err)
+			return
+		}
+		keys := configMapKeys(cm)
+
+		fmt.Println("====================================")
+		fmt.Printf("Keys of ConfigMap: \033[1;33m %s \033[0m \n", name)
+		fmt.Println("====================================")
+		printConfigMapKeyTable(keys)
+		fmt.Println("command action [v, ed, add, x, exit]: ")
+		fmt.Println("\u001B[0;31m v \u001B[0m: view key")
+		fmt.Println("\u001B[0;31m ed \u001B[0m: edit key in $EDITOR")
+		fmt.Println("\u001B[0;31m add \u001B[0m: add or replace key from local file")
+		fmt.Println("\u001B[0;31m x \u001B[0m: export keys to local directory")
+		fmt.Println("\u001B[0;31m exit \u001B[0m: quit current action")
+
+		action, _ := line.Prompt("Enter action: ")
+		action = strings.TrimSpace(action)
+
+		switch action {
+		case "v":
+			if key, ok := promptConfigMapKey(line, keys); ok {
+				viewConfigMapKey(cm, key)
+			}
+		case "ed":
+			if key, ok := promptConfigMapKey(line, keys); ok {
+				editConfigMapKey(cm, key)
+			}
+		case "add":
+			addConfigMapKeyFromFile(line, cm)
+		case "x":
+			exportConfigMapKeys(line, cm, keys)
+		default:
+			shouldReturn := checkExitCode(action)
+			if shouldReturn {
+				return
+			}
+			fmt.Println("Invalid action")
+		}
+	}
+}
+
+func promptConfigMapKey(line *liner.State, keys []configMapKey) (configMapKey, bool) {
+	if len(keys) == 0 {
+		fmt.Println("ConfigMap has no keys")
+		return configMapKey{}, false
+	}
+	input, _ := line.Prompt("Enter key number: ")
+	num, err := strconv.Atoi(strings.TrimSpace(input))
+	if err != nil || num < 0 || num >= len(keys) {
+		fmt.Println("Invalid key number")
+		return configMapKey{}, false
+	}
+	return keys[num], true
+}
+
+func viewConfigMapKey(cm *v1.ConfigMap, key configMapKey) {
+	fmt.Printf("==============%s (%s)======================\n", key.Name, formatBytes(key.Size))
+	if key.Binary {
+		fmt.Print(hexPreview(cm.BinaryData[key.Name], 512))
+		return
+	}
+	fmt.Println(highlightByExtension(key.Name, cm.Data[key.Name]))
+}
+
+// 二进制内容只展示前 limit 个字节的十六进制
+func hexPreview(data []byte, limit int) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "binary data, %d bytes\n", len(data))
+	shown := data
+	if len(shown) > limit {
+		shown = shown[:limit]
+	}
+	for i := 0; i < len(shown); i += 16 {
+		end := i + 16
+		if end > len(shown) {
+			end = len(shown)
+		}
+		fmt.Fprintf(&sb, "%08x  % -48x  ", i, shown[i:end])
+		for _, b := range shown[i:end] {
+			if b >= 32 && b < 127 {
+				sb.WriteByte(b)
+			} else {
+				sb.WriteByte('.')
+			}
+		}
+		sb.WriteByte('\n')
+	}
+	if len(data) > limit {
+		fmt.Fprintf(&sb, "... %d more bytes\n", len(data)-limit)
+	}
+	return sb.String()
+}
+
+// 单独编辑一个 key 的内容
+func editConfigMapKey(cm *v1.ConfigMap, key configMapKey) {
+	if key.Binary {
+		fmt.Println("Binary keys can not be edited in $EDITOR, use 'add' to replace it from a local file")
+		return
+	}
+	oldValue := cm.Data[key.Name]
+	newValue, err := editInEditor("kube-ui-*-"+key.Name, oldValue)
+	if err != nil {
+		fmt.Printf("Error editing key %s: %v\n", key.Name, err)
+		return
+	}
+	if newValue == oldValue {
+		fmt.Println("Edit cancelled, no changes made.")
+		return
+	}
+	updated := cm.DeepCopy()
+	updated.Data[key.Name] = newValue
+	updateConfigMapKey(cm, updated, key.Name, oldValue, newValue)
+}
+
+// 展示差异, 确认后带 resourceVersion 更新 ConfigMap
+func updateConfigMapKey(cm, updated *v1.ConfigMap, key, oldValue, newValue string) {
+	if !printDiff(oldValue, newValue, "live/"+key, "new/"+key) {
+		return
+	}
+	confirm := false
+	survey.AskOne(&survey.Confirm{
+		Message: fmt.Sprintf("Save key %s of ConfigMap %s?", key, cm.Name),
+		Default: false,
+	}, &confirm)
+	if !confirm {
+		return
+	}
+	ref := configMapRef(cm.Name)
+	if !backupBeforeChange(ref, "edit") {
+		return
+	}
+	ctx, cancel := requestContext()
+	defer cancel()
+	_, err := k8sClient.CoreV1().ConfigMaps(*namespace).Update(ctx, updated, metav1.UpdateOptions{FieldManager: fieldManager})
+	auditAPI("edit-key", "update", ref.String()+":"+key, err)
+	if err != nil {
+		fmt.Printf("Error updating configmap %s: %v\n", cm.Name, err)
+		return
+	}
+	fmt.Printf("Key %s of ConfigMap %s saved\n", key, cm.Name)
+}
+
+// 从本地文件添加 key, 非 UTF-8 内容保存到 binaryData
+func addConfigMapKeyFromFile(line *liner.State, cm *v1.ConfigMap) {
+	path, _ := line.Prompt("Enter local file path: ")
+	path = strings.TrimSpace(path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Printf("Error reading %s: %v\n", path, err)
+		return
+	}
+	key, _ := line.Prompt(fmt.Sprintf("Enter key name (default %s): ", filepath.Base(path)))
+	key = strings.TrimSpace(key)
+	if key == "" {
+		key = filepath.Base(path)
+	}
+	if errs := validation.IsConfigMapKey(key); len(errs) > 0 {
+		fmt.Printf("Invalid key %q: %s\n", key, strings.Join(errs, ", "))
+		return
+	}
+
+	updated := cm.DeepCopy()
+	oldValue := cm.Data[key]
+	_, oldBinary := cm.BinaryData[key]
+	if utf8.Valid(data) {
+		if updated.Data == nil {
+			updated.Data = map[string]string{}
+		}
+		delete(updated.BinaryData, key)
+		updated.Data[key] = string(data)
+		if oldBinary {
+			oldValue = binarySummary(cm.BinaryData[key])
+		}
+		updateConfigMapKey(cm, updated, key, oldValue, string(data))
+		return
+	}
+
+	if updated.BinaryData == nil {
+		updated.BinaryData = map[string][]byte{}
+	}
+	delete(updated.Data, key)
+	updated.BinaryData[key] = data
+	newValue := binarySummary(data)
+	if oldBinary {
+		oldValue = binarySummary(cm.BinaryData[key])
+	}
+	updateConfigMapKey(cm, updated, key, oldValue, newValue)
+}
+
+// 二进制内容在 diff 中用长度和摘要表示
+func binarySummary(data []byte) string {
+	return fmt.Sprintf("(binary data, %d bytes, sha256 %x)\n", len(data), sha256.Sum256(data))
+}
+
+// 导出 key 到本地目录, 每个 key 一个文件
+func exportConfigMapKeys(line *liner.State, cm *v1.ConfigMap, keys []configMapKey) {
+	if len(keys) == 0 {
+		fmt.Println("ConfigMap has no keys")
+		return
+	}
+	input, _ := line.Prompt("Enter key numbers separated by ',' or 'all': ")
+	input = strings.TrimSpace(input)
+	var selected []configMapKey
+	if input == "all" || input == "" {
+		selected = keys
+	} else {
+		for _, s := range strings.Split(input, ",") {
+			num, err := strconv.Atoi(strings.TrimSpace(s))
+			if err != nil || num < 0 || num >= len(keys) {
+				fmt.Printf("Invalid key number %q\n", s)
+				return
+			}
+			selected = append(selected, keys[num])
+		}
+	}
+
+	dir, _ := line.Prompt(fmt.Sprintf("Enter local directory (default ./%s): ", cm.Name))
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		dir = cm.Name
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		fmt.Printf("Error creating %s: %v\n", dir, err)
+		return
+	}
+	for _, key := range selected {
+		var data []byte
+		if key.Binary {
+			data = cm.BinaryData[key.Name]
+		} else {
+			data = []byte(cm.Data[key.Name])
+		}
+		path := filepath.Join(dir, key.Name)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			fmt.Printf("Error writing %s: %v\n", path, err)
+			continue
+		}
+		fmt.Printf("Exported %s (%s) to %s\n", key.Name, formatBytes(len(data)), path)
+	}
+}
+
+var (
+	highlightComment = regexp.MustCompile(`^(\s*)([#;!].*)$`)
+	highlightYAMLKey = regexp.MustCompile(`^(\s*-?\s*)([^\s:#][^:#]*?)(:)(\s|$)`)
+	highlightSection = regexp.MustCompile(`^(\s*)(\[+[^\]]+\]+)(\s*)$`)
+	highlightKV      = regexp.MustCompile(`^(\s*)([^=:\s][^=:]*?)(\s*[=:]\s*)(.*)$`)
+	highlightJSON    = regexp.MustCompile(`("(?:[^"\\]|\\.)*")(\s*:)?|\b(true|false|null)\b|(-?\d+(?:\.\d+)?(?:[eE][+-]?\d+)?)`)
+)
+
+const (
+	colorKey     = "\033[0;36m"
+	colorString  = "\033[0;32m"
+	colorNumber  = "\033[0;35m"
+	colorComment = "\033[0;90m"
+	colorSection = "\033[1;33m"
+	colorReset   = "\033[0m"
+)
+
+// 根据 key 的扩展名做简单的语法高亮
+func highlightByExtension(key, content string) string {
+	ext := strings.ToLower(filepath.Ext(key))
+	switch ext {
+	case ".json":
+		return highlightJSONContent(content)
+	case ".yaml", ".yml":
+		return highlightLines(content, highlightYAMLLine)
+	case ".properties", ".env", ".conf", ".ini", ".cfg":
+		return highlightLines(content, highlightPropertiesLine)
+	case ".toml":
+		return highlightLines(content, highlightTOMLLine)
+	}
+	return content
+}
+
+func highlightLines(content string, f func(string) string) string {
+	lines := strings.Split(content, "\n")
+	for i, l := range lines {
+		lines[i] = f(l)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func highlightYAMLLine(l string) string {
+	if strings.HasPrefix(strings.TrimSpace(l), "#") {
+		return colorComment + l + colorReset
+	}
+	if m := highlightYAMLKey.FindStringSubmatchIndex(l); m != nil {
+		return l[:m[4]] + colorKey + l[m[4]:m[5]] + colorReset + l[m[5]:]
+	}
+	return l
+}
+
+func highlightPropertiesLine(l string) string {
+	if m := highlightComment.FindStringSubmatch(l); m != nil {
+		return m[1] + colorComment + m[2] + colorReset
+	}
+	if m := highlightSection.FindStringSubmatch(l); m != nil {
+		return m[1] + colorSection + m[2] + colorReset + m[3]
+	}
+	if m := highlightKV.FindStringSubmatch(l); m != nil {
+		return m[1] + colorKey + m[2] + colorReset + m[3] + colorString + m[4] + colorReset
+	}
+	return l
+}
+
+func highlightTOMLLine(l string) string {
+	if strings.HasPrefix(strings.TrimSpace(l), "#") {
+		return colorComment + l + colorReset
+	}
+	if m := highlightSection.FindStringSubmatch(l); m != nil {
+		return m[1] + colorSection + m[2] + colorReset + m[3]
+	}
+	if idx := strings.Index(l, "="); idx > 0 {
+		return colorKey + l[:idx] + colorReset + "=" + highlightJSONValue(l[idx+1:])
+	}
+	return l
+}
+
+// 格式化 JSON 后高亮, 无法解析时原样高亮
+func highlightJSONContent(content string) string {
+	var pretty bytes.Buffer
+	if err := json.Indent(&pretty, []byte(content), "", "  "); err == nil {
+		content = pretty.String()
+	}
+	return highlightJSONValue(content)
+}
+
+func highlightJSONValue(s string) string {
+	return highlightJSON.ReplaceAllStringFunc(s, func(m string) string {
+		sub := highlightJSON.FindStringSubmatch(m)
+		switch {
+		case sub[1] != "" && sub[2] != "":
+			return colorKey + sub[1] + colorReset + sub[2]
+		case sub[1] != "":
+			return colorString + sub[1] + colorReset
+		case sub[3] != "":
+			return colorSection + sub[3] + colorReset
+		case sub[4] != "":
+			return colorNumber + sub[4] + colorReset
+		}
+		return m
+	})
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestConfigMapKeys(t *testing.T) {
+	cm := &v1.ConfigMap{
+		Data:       map[string]string{"b.yaml": "a: 1\n", "a.env": ""},
+		BinaryData: map[string][]byte{"c.bin": {0, 1, 2}},
+	}
+	want := []configMapKey{{Name: "a.env", Size: 0}, {Name: "b.yaml", Size: 5}, {Name: "c.bin", Binary: true, Size: 3}}
+	if got := configMapKeys(cm); !reflect.DeepEqual(got, want) {
+		t.Errorf("configMapKeys() = %v, want %v", got, want)
+	}
+	if got := configMapSize(*cm); got != 8 {
+		t.Errorf("configMapSize() = %d, want 8", got)
+	}
+}
+
+func TestHexPreview(t *testing.T) {
+	tests := []struct {
+		data  []byte
+		limit int
+		want  string
+	}{
+		{[]byte{}, 16, "binary data, 0 bytes\n"},
+		{[]byte("AB\x00\xff"), 16, "binary data, 4 bytes\n" +
+			"00000000  41 42 00 ff                                       AB..\n"},
+		{[]byte("0123456789abcdefXYZ"), 16, "binary data, 19 bytes\n" +
+			"00000000  30 31 32 33 34 35 36 37 38 39 61 62 63 64 65 66   0123456789abcdef\n" +
+			"... 3 more bytes\n"},
+		{[]byte("0123456789abcdefXYZ"), 32, "binary data, 19 bytes\n" +
+			"00000000  30 31 32 33 34 35 36 37 38 39 61 62 63 64 65 66   0123456789abcdef\n" +
+			"00000010  58 59 5a                                          XYZ\n"},
+	}
+	for _, tt := range tests {
+		if got := hexPreview(tt.data, tt.limit); got != tt.want {
+			t.Errorf("hexPreview(%q, %d) =\n%s\nwant\n%s", tt.data, tt.limit, got, tt.want)
+		}
+	}
+}
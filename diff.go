@@ -0,0 +1,204 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+const diffContext = 3
+
+type diffLine struct {
+	op   byte // ' ', '-', '+'
+	text string
+}
+
+// 生成 unified diff, 内容相同时返回空字符串
+func unifiedDiff(oldText, newText, oldName, newName string) string {
+	if oldText == newText {
+		return ""
+	}
+	lines := diffLines(splitLines(oldText), splitLines(newText))
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldName, newName)
+
+	// 按变更位置切分 hunk, 每个 hunk 前后保留 diffContext 行上下文
+	oldLine, newLine := 1, 1
+	for i := 0; i < len(lines); {
+		if lines[i].op == ' ' {
+			oldLine++
+			newLine++
+			i++
+			continue
+		}
+		start := i - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for end < len(lines) {
+			if lines[end].op != ' ' {
+				end++
+				continue
+			}
+			// 连续超过 2*diffContext 行未变化时结束当前 hunk
+			next := end
+			for next < len(lines) && lines[next].op == ' ' {
+				next++
+			}
+			if next == len(lines) || next-end > 2*diffContext {
+				break
+			}
+			end = next
+		}
+		stop := end + diffContext
+		if stop > len(lines) {
+			stop = len(lines)
+		}
+
+		hunkOld, hunkNew := oldLine-(i-start), newLine-(i-start)
+		oldCount, newCount := 0, 0
+		var body strings.Builder
+		for _, l := range lines[start:stop] {
+			body.WriteByte(l.op)
+			body.WriteString(l.text)
+			body.WriteByte('\n')
+			if l.op != '+' {
+				oldCount++
+			}
+			if l.op != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n", hunkOld, oldCount, hunkNew, newCount)
+		sb.WriteString(body.String())
+
+		for _, l := range lines[i:stop] {
+			if l.op != '+' {
+				oldLine++
+			}
+			if l.op != '-' {
+				newLine++
+			}
+		}
+		i = stop
+	}
+	return sb.String()
+}
+
+// 给 diff 加上颜色
+func colorizeDiff(diff string) string {
+	var sb strings.Builder
+	for _, l := range splitLines(diff) {
+		switch {
+		case strings.HasPrefix(l, "+++"), strings.HasPrefix(l, "---"):
+			sb.WriteString("\033[1m" + l + "\033[0m")
+		case strings.HasPrefix(l, "@@"):
+			sb.WriteString("\033[0;36m" + l + "\033[0m")
+		case strings.HasPrefix(l, "+"):
+			sb.WriteString("\033[0;32m" + l + "\033[0m")
+		case strings.HasPrefix(l, "-"):
+			sb.WriteString("\033[0;31m" + l + "\033[0m")
+		default:
+			sb.WriteString(l)
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// 打印带颜色的 diff, 返回是否有差异
+func printDiff(oldText, newText, oldName, newName string) bool {
+	diff := unifiedDiff(oldText, newText, oldName, newName)
+	if diff == "" {
+		fmt.Println("No differences")
+		return false
+	}
+	fmt.Print(colorizeDiff(diff))
+	return true
+}
+
+func splitLines(s string) []string {
+	s = strings.TrimSuffix(s, "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// 最长公共子序列矩阵的最大单元数, 超过时不逐行比较, 避免大对象占用过多内存
+const diffMaxCells = 1 << 20
+
+// 基于最长公共子序列计算逐行差异, 先去掉相同的首尾减少计算量
+// 去掉首尾后仍然太大时, 中间部分整体显示为删除和新增
+func diffLines(a, b []string) []diffLine {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var result []diffLine
+	for _, l := range a[:prefix] {
+		result = append(result, diffLine{' ', l})
+	}
+
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	n, m := len(midA), len(midB)
+	if (n+1)*(m+1) > diffMaxCells {
+		for _, l := range midA {
+			result = append(result, diffLine{'-', l})
+		}
+		for _, l := range midB {
+			result = append(result, diffLine{'+', l})
+		}
+		for _, l := range a[len(a)-suffix:] {
+			result = append(result, diffLine{' ', l})
+		}
+		return result
+	}
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if midA[i] == midB[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case midA[i] == midB[j]:
+			result = append(result, diffLine{' ', midA[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			result = append(result, diffLine{'-', midA[i]})
+			i++
+		default:
+			result = append(result, diffLine{'+', midB[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		result = append(result, diffLine{'-', midA[i]})
+	}
+	for ; j < m; j++ {
+		result = append(result, diffLine{'+', midB[j]})
+	}
+
+	for _, l := range a[len(a)-suffix:] {
+		result = append(result, diffLine{' ', l})
+	}
+	return result
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want []string
+	}{
+		{"equal", "a\nb", "a\nb", []string{" a", " b"}},
+		{"empty old", "", "a\nb", []string{"+a", "+b"}},
+		{"empty new", "a\nb", "", []string{"-a", "-b"}},
+		{"change in middle", "a\nb\nc", "a\nx\nc", []string{" a", "-b", "+x", " c"}},
+		{"insert", "a\nc", "a\nb\nc", []string{" a", "+b", " c"}},
+		{"delete", "a\nb\nc", "a\nc", []string{" a", "-b", " c"}},
+		{"move", "a\nb\nc", "b\nc\na", []string{"-a", " b", " c", "+a"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, l := range diffLines(splitLines(tt.a), splitLines(tt.b)) {
+			got = append(got, string(l.op)+l.text)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: diffLines = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// 中间部分太大时整体替换, 相同的首尾仍然保留
+func TestDiffLinesTooLarge(t *testing.T) {
+	a, b := []string{"head"}, []string{"head"}
+	for i := 0; i < 1100; i++ {
+		a = append(a, fmt.Sprintf("old-%d", i))
+		b = append(b, fmt.Sprintf("new-%d", i))
+	}
+	a, b = append(a, "tail"), append(b, "tail")
+	lines := diffLines(a, b)
+	if len(lines) != 2202 {
+		t.Fatalf("diffLines returned %d lines, want 2202", len(lines))
+	}
+	if lines[0].op != ' ' || lines[1].op != '-' || lines[1101].op != '+' || lines[2201] != (diffLine{' ', "tail"}) {
+		t.Errorf("diffLines = %q ... %q", lines[:2], lines[1100:1102])
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	if diff := unifiedDiff("a\nb\n", "a\nb\n", "old", "new"); diff != "" {
+		t.Errorf("unifiedDiff of equal text = %q, want empty", diff)
+	}
+
+	var oldLines, newLines []string
+	for i := 1; i <= 20; i++ {
+		line := strings.Repeat("x", i)
+		oldLines = append(oldLines, line)
+		if i == 2 || i == 18 {
+			line = "changed"
+		}
+		newLines = append(newLines, line)
+	}
+	diff := unifiedDiff(strings.Join(oldLines, "\n"), strings.Join(newLines, "\n"), "old", "new")
+	want := []string{"--- old", "+++ new", "@@ -1,5 +1,5 @@", "@@ -15,6 +15,6 @@"}
+	for _, w := range want {
+		if !strings.Contains(diff, w+"\n") {
+			t.Errorf("unifiedDiff missing %q in:\n%s", w, diff)
+		}
+	}
+	if n := strings.Count(diff, "@@ -"); n != 2 {
+		t.Errorf("unifiedDiff has %d hunks, want 2:\n%s", n, diff)
+	}
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+const editHeader = `# Please edit the object below. Lines beginning with a '#' will be ignored,
+# and an empty file will abort the edit. If an error occurs while saving this file will be
+# reopened with the relevant failures.
+#
+`
+
+// 使用 $KUBE_EDITOR 或 $EDITOR 打开文件, 等待编辑器退出
+func openEditor(path string) error {
+	editor := os.Getenv("KUBE_EDITOR")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+	// 编辑器可能带参数, 例如 "code -w"
+	fields := strings.Fields(editor)
+	cmd := exec.Command(fields[0], append(fields[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// 将内容写入临时文件并打开编辑器, 返回编辑后的内容
+func editInEditor(pattern, content string) (string, error) {
+	f, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return "", err
+	}
+	path := f.Name()
+	defer os.Remove(path)
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		return "", err
+	}
+	f.Close()
+
+	if err := openEditor(path); err != nil {
+		return "", fmt.Errorf("editor failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// 去掉顶格的注释行, 缩进的 '#' 可能是 ConfigMap 中的配置内容
+func stripCommentLines(s string) string {
+	var lines []string
+	for _, l := range strings.Split(s, "\n") {
+		if strings.HasPrefix(l, "#") {
+			continue
+		}
+		lines = append(lines, l)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func errorHeader(err error) string {
+	var sb strings.Builder
+	sb.WriteString(editHeader)
+	for _, l := range strings.Split(strings.TrimSpace(err.Error()), "\n") {
+		sb.WriteString("# error: " + l + "\n")
+	}
+	sb.WriteString("#\n")
+	return sb.String()
+}
+
+func handleEditAction(ref resourceRef) {
+	if err := editResource(ref); err != nil {
+		fmt.Println(err)
+	}
+}
+
+// 在 kube-ui 中编辑任意对象: 校验, dry-run, 展示差异, 确认后带 resourceVersion 更新
+// 用户取消时返回 nil
+func editResource(ref resourceRef) error {
+	live, err := getLiveObject(ref)
+	if err != nil {
+		return fmt.Errorf("Error getting %s: %v", ref, err)
+	}
+	resourceVersion := live.GetResourceVersion()
+
+	original := live.DeepCopy()
+	stripNoiseFields(original)
+	originalText, err := objectToYAML(original)
+	if err != nil {
+		return fmt.Errorf("Error converting %s to yaml: %v", ref, err)
+	}
+
+	header := editHeader
+	content := originalText
+	for {
+		edited, err := editInEditor(fmt.Sprintf("kube-ui-%s-%s-*.yaml", strings.ToLower(ref.Kind), ref.Name), header+content)
+		if err != nil {
+			return fmt.Errorf("Error editing %s: %v", ref, err)
+		}
+		content = stripCommentLines(edited)
+		if strings.TrimSpace(content) == "" {
+			fmt.Println("Edit cancelled, no changes made.")
+			return nil
+		}
+		if strings.TrimSpace(content) == strings.TrimSpace(originalText) {
+			fmt.Println("Edit cancelled, no changes made.")
+			return nil
+		}
+
+		var dryRun *unstructured.Unstructured
+		obj, err := validateEditedObject(ref, content)
+		if err == nil {
+			obj.SetResourceVersion(resourceVersion)
+			ctx, cancel := requestContext()
+			dryRun, err = ref.client().Update(ctx, obj, metav1.UpdateOptions{
+				FieldManager:    fieldManager,
+				FieldValidation: "Strict",
+				DryRun:          []string{metav1.DryRunAll},
+			})
+			err = requestError(ctx, err)
+			cancel()
+		}
+		if err != nil {
+			// 出错时带着错误信息重新打开编辑器
+			fmt.Printf("Error: %v\n", err)
+			header = errorHeader(err)
+			continue
+		}
+
+		// 对比 dry-run 的结果, 包含服务端填充的默认值
+		stripNoiseFields(dryRun)
+		dryRunText, _ := objectToYAML(dryRun)
+		if !printDiff(originalText, dryRunText, "live/"+ref.String(), "edited/"+ref.String()) {
+			return nil
+		}
+
+		confirm := false
+		survey.AskOne(&survey.Confirm{
+			Message: fmt.Sprintf("Save changes to %s?", ref),
+			Default: false,
+		}, &confirm)
+		if !confirm {
+			fmt.Println("Edit cancelled, no changes made.")
+			return nil
+		}
+
+		if !backupBeforeChange(ref, "edit") {
+			return fmt.Errorf("edit of %s cancelled, backup failed", ref)
+		}
+		ctx, cancel := requestContext()
+		_, err = ref.client().Update(ctx, obj, metav1.UpdateOptions{
+			FieldManager:    fieldManager,
+			FieldValidation: "Strict",
+		})
+		err = requestError(ctx, err)
+		cancel()
+		auditAPI("edit", "update", ref.String(), err)
+		if apierrors.IsConflict(err) {
+			// 编辑期间对象被其他人修改
+			path := saveEditedCopy(ref, content)
+			fmt.Printf("\033[1;31m%s has been modified since you started editing, changes were not saved.\033[0m\n", ref)
+			if path != "" {
+				fmt.Printf("Your edits were saved to %s\n", path)
+			}
+			reload := false
+			survey.AskOne(&survey.Confirm{
+				Message: "Reload the latest version and edit again?",
+				Default: true,
+			}, &reload)
+			if reload {
+				return editResource(ref)
+			}
+			return fmt.Errorf("Error updating %s: %v", ref, err)
+		}
+		if err != nil {
+			return fmt.Errorf("Error updating %s: %v", ref, err)
+		}
+		fmt.Printf("%s edited\n", ref)
+		return nil
+	}
+}
+
+// 解析编辑后的 YAML, 不允许修改对象的类型和名称
+func validateEditedObject(ref resourceRef, content string) (*unstructured.Unstructured, error) {
+	obj := &unstructured.Unstructured{}
+	if err := yaml.Unmarshal([]byte(content), &obj.Object); err != nil {
+		return nil, fmt.Errorf("invalid yaml: %v", err)
+	}
+	if obj.Object == nil {
+		return nil, fmt.Errorf("invalid yaml: empty object")
+	}
+	if obj.GetKind() != ref.Kind {
+		return nil, fmt.Errorf("kind can not be changed from %q to %q", ref.Kind, obj.GetKind())
+	}
+	if obj.GetName() != ref.Name {
+		return nil, fmt.Errorf("metadata.name can not be changed from %q to %q", ref.Name, obj.GetName())
+	}
+	if ref.Namespace != "" && obj.GetNamespace() != ref.Namespace {
+		return nil, fmt.Errorf("metadata.namespace can not be changed from %q to %q", ref.Namespace, obj.GetNamespace())
+	}
+	return obj, nil
+}
+
+// 更新冲突时保存用户的编辑内容, 避免丢失
+func saveEditedCopy(ref resourceRef, content string) string {
+	f, err := os.CreateTemp("", fmt.Sprintf("kube-ui-edit-%s-%s-*.yaml", strings.ToLower(ref.Kind), ref.Name))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	if _, err := f.WriteString(content); err != nil {
+		return ""
+	}
+	return f.Name()
+}
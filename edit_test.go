@@ -0,0 +1,23 @@
+package main
+
+import "testing"
+
+func TestValidateEditedObject(t *testing.T) {
+	ref := resourceRef{GVR: configMapsGVR, Kind: "ConfigMap", Namespace: "prod", Name: "app"}
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{"valid", "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\n  namespace: prod\n", false},
+		{"invalid yaml", "kind: [", true},
+		{"kind changed", "apiVersion: v1\nkind: Secret\nmetadata:\n  name: app\n  namespace: prod\n", true},
+		{"namespace changed", "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\n  namespace: dev\n", true},
+	}
+	for _, tt := range tests {
+		_, err := validateEditedObject(ref, tt.content)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: validateEditedObject error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,359 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+// 快照文件名: <时间>-<操作>.yaml, 同一秒内的多次备份用毫秒区分, 仍然重名时加上 -2, -3 等后缀
+const snapshotTimeFormat = "20060102-150405.000"
+
+// 旧版本只精确到秒
+const legacySnapshotTimeFormat = "20060102-150405"
+
+// 本地保存的一份对象快照
+type snapshot struct {
+	Path      string
+	Time      time.Time
+	Operation string
+	Kind      string
+	Name      string
+}
+
+// 快照目录: ~/.kube-ui.d/history/<cluster>/<namespace>/<kind>/<name>
+func historyDir(ref resourceRef) (string, error) {
+	dataDir, err := kubeUIDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, "history", historyClusterDir(), historyNamespaceDir(ref.Namespace), strings.ToLower(ref.Kind), ref.Name), nil
+}
+
+func historyClusterDir() string {
+	for _, name := range []string{auditCluster, auditContext, currentConfig.Name} {
+		if name != "" {
+			return sanitizePathComponent(name)
+		}
+	}
+	return "default"
+}
+
+func historyNamespaceDir(ns string) string {
+	if ns == "" {
+		return "_cluster"
+	}
+	return ns
+}
+
+func sanitizePathComponent(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|':
+			return '_'
+		}
+		return r
+	}, s)
+}
+
+// 在破坏性操作前保存对象当前状态, 返回是否继续执行操作
+func backupBeforeChange(ref resourceRef, operation string) bool {
+	path, err := backupObject(ref, operation)
+	if err == nil {
+		if path != "" {
+			fmt.Printf("Backup of %s saved to %s\n", ref, path)
+		}
+		return true
+	}
+	fmt.Printf("Error backing up %s: %v\n", ref, err)
+	confirm := false
+	survey.AskOne(&survey.Confirm{
+		Message: "Backup failed, continue anyway?",
+		Default: false,
+	}, &confirm)
+	return confirm
+}
+
+// 保存对象快照, 对象不存在时不保存并返回空路径
+func backupObject(ref resourceRef, operation string) (string, error) {
+	obj, err := getLiveObject(ref)
+	if apierrors.IsNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	obj.SetManagedFields(nil)
+	data, err := yaml.Marshal(obj.Object)
+	if err != nil {
+		return "", err
+	}
+
+	dir, err := historyDir(ref)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	now := time.Now()
+	for n := 1; ; n++ {
+		path := filepath.Join(dir, snapshotFileName(now, operation, n))
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		if _, err := f.Write(data); err != nil {
+			f.Close()
+			return "", err
+		}
+		return path, f.Close()
+	}
+}
+
+func snapshotFileName(t time.Time, operation string, n int) string {
+	if n > 1 {
+		return fmt.Sprintf("%s-%s-%d.yaml", t.Format(snapshotTimeFormat), operation, n)
+	}
+	return fmt.Sprintf("%s-%s.yaml", t.Format(snapshotTimeFormat), operation)
+}
+
+// 解析快照文件名中的时间和操作, 兼容只精确到秒的旧文件名
+func parseSnapshotFileName(fileName string) (time.Time, string, bool) {
+	base, ok := strings.CutSuffix(fileName, ".yaml")
+	if !ok {
+		return time.Time{}, "", false
+	}
+	for _, format := range []string{snapshotTimeFormat, legacySnapshotTimeFormat} {
+		if len(base) < len(format)+2 || base[len(format)] != '-' {
+			continue
+		}
+		t, err := time.ParseInLocation(format, base[:len(format)], time.Local)
+		if err != nil {
+			continue
+		}
+		operation := base[len(format)+1:]
+		if i := strings.LastIndex(operation, "-"); i > 0 {
+			if _, err := strconv.Atoi(operation[i+1:]); err == nil {
+				operation = operation[:i]
+			}
+		}
+		return t, operation, true
+	}
+	return time.Time{}, "", false
+}
+
+// 列出某个对象的所有快照, 最新的在前
+func listSnapshots(ref resourceRef) ([]snapshot, error) {
+	dir, err := historyDir(ref)
+	if err != nil {
+		return nil, err
+	}
+	return readSnapshotDir(dir, ref.Kind, ref.Name)
+}
+
+func readSnapshotDir(dir, kind, name string) ([]snapshot, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var snapshots []snapshot
+	for _, entry := range entries {
+		fileName := entry.Name()
+		if entry.IsDir() {
+			continue
+		}
+		t, operation, ok := parseSnapshotFileName(fileName)
+		if !ok {
+			continue
+		}
+		snapshots = append(snapshots, snapshot{
+			Path:      filepath.Join(dir, fileName),
+			Time:      t,
+			Operation: operation,
+			Kind:      kind,
+			Name:      name,
+		})
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		if snapshots[i].Time.Equal(snapshots[j].Time) {
+			// 同一毫秒内的备份, 带序号后缀的文件名更长, 是后保存的
+			return len(snapshots[i].Path) > len(snapshots[j].Path)
+		}
+		return snapshots[i].Time.After(snapshots[j].Time)
+	})
+	return snapshots, nil
+}
+
+// 列出当前 namespace 下所有有快照的对象, 包括已经删除的对象
+func handleHistoryAction() {
+	dataDir, err := kubeUIDataDir()
+	if err != nil {
+		fmt.Printf("Error reading history: %v\n", err)
+		return
+	}
+	nsDir := filepath.Join(dataDir, "history", historyClusterDir(), historyNamespaceDir(*namespace))
+	kinds, err := os.ReadDir(nsDir)
+	if err != nil && !os.IsNotExist(err) {
+		fmt.Printf("Error reading history: %v\n", err)
+		return
+	}
+
+	var options []string
+	var latest []snapshot
+	for _, kind := range kinds {
+		if !kind.IsDir() {
+			continue
+		}
+		names, _ := os.ReadDir(filepath.Join(nsDir, kind.Name()))
+		for _, name := range names {
+			snapshots, _ := readSnapshotDir(filepath.Join(nsDir, kind.Name(), name.Name()), kind.Name(), name.Name())
+			if len(snapshots) == 0 {
+				continue
+			}
+			latest = append(latest, snapshots[0])
+			options = append(options, fmt.Sprintf("%s/%s (%d snapshots, latest %s)", kind.Name(), name.Name(), len(snapshots), snapshots[0].Time.Format("2006-01-02 15:04:05")))
+		}
+	}
+	if len(options) == 0 {
+		fmt.Println("No snapshots in namespace", *namespace)
+		return
+	}
+	options = append(options, "exit")
+
+	var selected int
+	if err := survey.AskOne(&survey.Select{
+		Message: "choose object to restore:",
+		Options: options,
+	}, &selected); err != nil || selected == len(options)-1 {
+		return
+	}
+
+	// 从快照中得到对象的 GVR
+	data, err := os.ReadFile(latest[selected].Path)
+	if err != nil {
+		fmt.Printf("Error reading snapshot: %v\n", err)
+		return
+	}
+	obj := &unstructured.Unstructured{}
+	if err := yaml.Unmarshal(data, &obj.Object); err != nil {
+		fmt.Printf("Error parsing snapshot: %v\n", err)
+		return
+	}
+	ref, err := refForObject(obj)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	handleRestoreAction(ref)
+}
+
+func handleRestoreAction(ref resourceRef) {
+	if err := restoreResource(ref); err != nil {
+		fmt.Println(err)
+	}
+}
+
+// 选择快照, 对比当前状态后重新应用, 用户取消时返回 nil
+func restoreResource(ref resourceRef) error {
+	snapshots, err := listSnapshots(ref)
+	if err != nil {
+		return fmt.Errorf("Error listing snapshots: %v", err)
+	}
+	if len(snapshots) == 0 {
+		return fmt.Errorf("No snapshots for %s", ref)
+	}
+
+	table := newOutputTable("Number", "Time", "Operation", "File")
+	for i, s := range snapshots {
+		table.append(&snapshots[i], fmt.Sprintf("%d", i), s.Time.Format("2006-01-02 15:04:05"), s.Operation, s.Path)
+	}
+	table.render()
+
+	input, _ := line.Prompt("Enter snapshot number to restore, exit to quit: ")
+	input = strings.TrimSpace(input)
+	if checkExitCode(input) {
+		return nil
+	}
+	var index int
+	if _, err := fmt.Sscanf(input, "%d", &index); err != nil || index < 0 || index >= len(snapshots) {
+		return fmt.Errorf("Invalid snapshot number %q", input)
+	}
+
+	data, err := os.ReadFile(snapshots[index].Path)
+	if err != nil {
+		return fmt.Errorf("Error reading snapshot: %v", err)
+	}
+	desired := &unstructured.Unstructured{}
+	if err := yaml.Unmarshal(data, &desired.Object); err != nil {
+		return fmt.Errorf("Error parsing snapshot: %v", err)
+	}
+
+	live, err := getLiveObject(ref)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("Error getting %s: %v", ref, err)
+	}
+	if err != nil {
+		live = nil
+	}
+
+	// 对比当前状态和快照
+	liveText := ""
+	if live != nil {
+		liveCopy := live.DeepCopy()
+		stripNoiseFields(liveCopy)
+		liveText, _ = objectToYAML(liveCopy)
+	}
+	desiredCopy := desired.DeepCopy()
+	stripNoiseFields(desiredCopy)
+	desiredText, _ := objectToYAML(desiredCopy)
+	if !printDiff(liveText, desiredText, "live/"+ref.String(), "snapshot/"+filepath.Base(snapshots[index].Path)) {
+		return nil
+	}
+
+	confirm := false
+	survey.AskOne(&survey.Confirm{
+		Message: fmt.Sprintf("Restore %s from snapshot %s?", ref, snapshots[index].Time.Format("2006-01-02 15:04:05")),
+		Default: false,
+	}, &confirm)
+	if !confirm {
+		return nil
+	}
+
+	ctx, cancel := requestContext()
+	defer cancel()
+	if live != nil {
+		if !backupBeforeChange(ref, "restore") {
+			return fmt.Errorf("restore of %s cancelled, backup failed", ref)
+		}
+		// 使用当前的 resourceVersion 覆盖
+		desiredCopy.SetResourceVersion(live.GetResourceVersion())
+		_, err = ref.client().Update(ctx, desiredCopy, metav1.UpdateOptions{FieldManager: fieldManager})
+		auditAPI("restore", "update", ref.String(), err)
+	} else {
+		desiredCopy.SetOwnerReferences(nil)
+		_, err = ref.client().Create(ctx, desiredCopy, metav1.CreateOptions{FieldManager: fieldManager})
+		auditAPI("restore", "create", ref.String(), err)
+	}
+	if err != nil {
+		return fmt.Errorf("Error restoring %s: %v", ref, requestError(ctx, err))
+	}
+	fmt.Printf("%s restored\n", ref)
+	return nil
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// 快照文件名包含修改时间和操作, 同一时间的多个快照带序号
+func TestParseSnapshotFileName(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 5e6, time.Local)
+	for n := 1; n <= 3; n++ {
+		got, operation, ok := parseSnapshotFileName(snapshotFileName(now, "bulk-label", n))
+		if !ok || !got.Equal(now) || operation != "bulk-label" {
+			t.Errorf("round trip of snapshot %d = %v, %q, %v", n, got, operation, ok)
+		}
+	}
+	if _, _, ok := parseSnapshotFileName("notes.yaml"); ok {
+		t.Error("parseSnapshotFileName accepted notes.yaml")
+	}
+}
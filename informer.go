@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+)
+
+// 每个命名空间共用一个 informer 工厂, 切换命名空间时停止旧的
+var (
+	informerMu        sync.Mutex
+	informerFactory   informers.SharedInformerFactory
+	informerNamespace string
+	informerStop      chan struct{}
+)
+
+func sharedInformerFactory(ns string) informers.SharedInformerFactory {
+	informerMu.Lock()
+	defer informerMu.Unlock()
+	if informerFactory != nil && informerNamespace == ns {
+		return informerFactory
+	}
+	if informerStop != nil {
+		close(informerStop)
+	}
+	informerStop = make(chan struct{})
+	informerNamespace = ns
+	// 首次 List 使用 resourceVersion=0, 由 API server 的 watch 缓存返回, 不读 etcd;
+	// 带 Limit 分页获取, 返回 Continue 时继续获取下一页. 之后列表都从本地缓存读取
+	informerFactory = informers.NewSharedInformerFactoryWithOptions(k8sClient, 0, informers.WithNamespace(ns))
+	return informerFactory
+}
+
+// 资源对应的 informer, 第一次使用时启动并等待同步
+// 首次同步受请求超时和 Ctrl+C 控制, 中断后 informer 继续在后台同步
+func resourceInformer(ns, resource string) (cache.SharedIndexInformer, error) {
+	ctx, cancel := requestContext()
+	defer cancel()
+	return resourceInformerContext(ctx, ns, resource)
+}
+
+// 等待首次同步直到 ctx 取消, 用于自己处理取消的调用方, 例如全屏模式
+func resourceInformerContext(ctx context.Context, ns, resource string) (cache.SharedIndexInformer, error) {
+	factory := sharedInformerFactory(ns)
+	var informer cache.SharedIndexInformer
+	switch resource {
+	case "pods":
+		informer = factory.Core().V1().Pods().Informer()
+	case "deployments":
+		informer = factory.Apps().V1().Deployments().Informer()
+	case "svc":
+		informer = factory.Core().V1().Services().Informer()
+	case "configmap":
+		informer = factory.Core().V1().ConfigMaps().Informer()
+	case "pvc":
+		informer = factory.Core().V1().PersistentVolumeClaims().Informer()
+	case "pv":
+		informer = factory.Core().V1().PersistentVolumes().Informer()
+	default:
+		return nil, fmt.Errorf("no informer for %s", resource)
+	}
+
+	informerMu.Lock()
+	stop := informerStop
+	informerMu.Unlock()
+	factory.Start(stop)
+
+	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("loading %s: %v", resource, requestError(ctx, ctx.Err()))
+		}
+		return nil, fmt.Errorf("%s informer stopped before syncing", resource)
+	}
+	return informer, nil
+}
+
+// informer 缓存中的对象, 按名称排序
+func sortedInformerObjects(informer cache.SharedIndexInformer) []interface{} {
+	objs := informer.GetStore().List()
+	sort.Slice(objs, func(i, j int) bool {
+		a, _ := meta.Accessor(objs[i])
+		b, _ := meta.Accessor(objs[j])
+		if a == nil || b == nil {
+			return false
+		}
+		return a.GetName() < b.GetName()
+	})
+	return objs
+}
+
+// informer 缓存中的资源, 第一次使用时启动 informer, 之后直接读取缓存
+// 修改过的对象先等待缓存追上, 避免操作后显示旧的状态
+func cachedList[T any](resource string) ([]T, error) {
+	informer, err := resourceInformer(*namespace, resource)
+	if err != nil {
+		return nil, err
+	}
+	waitForCacheExpectations(resource, informer)
+	return sortedItems[T](resource, sortedInformerObjects(informer)), nil
+}
+
+// 修改后缓存中应该看到的对象版本, deleted 表示对象已删除或正在删除
+type cacheExpectation struct {
+	key             string
+	resourceVersion string
+	deleted         bool
+}
+
+// 等待缓存追上的最长时间, 超时后显示缓存中的内容
+const cacheCatchUpTimeout = 3 * time.Second
+
+var (
+	cacheExpectationsMu sync.Mutex
+	cacheExpectations   = map[string][]cacheExpectation{}
+)
+
+func cacheKey(ref resourceRef) string {
+	if ref.Namespace == "" {
+		return ref.Name
+	}
+	return ref.Namespace + "/" + ref.Name
+}
+
+// 记录修改后的对象, obj 为修改返回的对象, 为 nil 时表示已删除
+func expectInCache(ref resourceRef, obj metav1.Object) {
+	resource := informerResourceFor(ref)
+	if resource == "" {
+		return
+	}
+	e := cacheExpectation{key: cacheKey(ref), deleted: obj == nil}
+	if obj != nil {
+		e.resourceVersion = obj.GetResourceVersion()
+		e.deleted = obj.GetDeletionTimestamp() != nil
+	}
+	cacheExpectationsMu.Lock()
+	defer cacheExpectationsMu.Unlock()
+	cacheExpectations[resource] = append(cacheExpectations[resource], e)
+}
+
+// 操作后读取对象的当前版本, 适用于通过 kubectl 等无法得到结果的修改
+func expectLiveInCache(ref resourceRef) {
+	live, err := getLiveObject(ref)
+	switch {
+	case apierrors.IsNotFound(err):
+		expectInCache(ref, nil)
+	case err == nil:
+		expectInCache(ref, live)
+	}
+}
+
+func waitForCacheExpectations(resource string, informer cache.SharedIndexInformer) {
+	cacheExpectationsMu.Lock()
+	expectations := cacheExpectations[resource]
+	delete(cacheExpectations, resource)
+	cacheExpectationsMu.Unlock()
+
+	deadline := time.Now().Add(cacheCatchUpTimeout)
+	for _, e := range expectations {
+		for !cacheObserved(informer, e) {
+			if time.Now().After(deadline) {
+				fmt.Printf("\033[2mThe %s cache has not caught up with the latest changes yet, the list may be out of date\033[0m\n", resource)
+				return
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+}
+
+func cacheObserved(informer cache.SharedIndexInformer, e cacheExpectation) bool {
+	obj, exists, err := informer.GetStore().GetByKey(e.key)
+	if err != nil {
+		return true
+	}
+	if !exists {
+		return e.deleted
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return true
+	}
+	if e.deleted {
+		return accessor.GetDeletionTimestamp() != nil
+	}
+	return accessor.GetResourceVersion() == e.resourceVersion
+}
+
+// 资源对应的 informer 名称, 没有 informer 的资源返回空
+func informerResourceFor(ref resourceRef) string {
+	switch ref.GVR {
+	case podsGVR:
+		return "pods"
+	case deploymentsGVR:
+		return "deployments"
+	case servicesGVR:
+		return "svc"
+	case configMapsGVR:
+		return "configmap"
+	case pvcsGVR:
+		return "pvc"
+	case pvsGVR:
+		return "pv"
+	}
+	return ""
+}
+
+// 转换为对象列表并按当前排序排列
+func sortedItems[T any](resource string, objs []interface{}) []T {
+	items := informerItems[T](objs)
+	// 配置中无效的排序在启动时已经提示过
+	sortItems(resource, items)
+	return items
+}
+
+func informerItems[T any](objs []interface{}) []T {
+	items := make([]T, 0, len(objs))
+	for _, o := range objs {
+		items = append(items, *o.(*T))
+	}
+	return items
+}
+
+// 把 informer 中的对象转换为资源列表, 复用各资源的表格
+func informerTable(resource string, objs []interface{}, filter string) *outputTable {
+	switch resource {
+	case "pods":
+		list := &v1.PodList{Items: sortedItems[v1.Pod](resource, objs)}
+		return buildPodTable(list, filter, func(pod v1.Pod, input string) bool { return nameContains(pod.Name, input) })
+	case "deployments":
+		list := &appsv1.DeploymentList{Items: sortedItems[appsv1.Deployment](resource, objs)}
+		return buildDeploymentTable(list, filter, func(d appsv1.Deployment, input string) bool { return nameContains(d.Name, input) })
+	case "svc":
+		list := &v1.ServiceList{Items: sortedItems[v1.Service](resource, objs)}
+		return buildSvcTable(list, filter, func(svc v1.Service, input string) bool { return nameContains(svc.Name, input) })
+	case "configmap":
+		list := &v1.ConfigMapList{Items: sortedItems[v1.ConfigMap](resource, objs)}
+		return buildConfigMapTable(list, filter, func(cm v1.ConfigMap, input string) bool { return nameContains(cm.Name, input) })
+	case "pvc":
+		list := &v1.PersistentVolumeClaimList{Items: sortedItems[v1.PersistentVolumeClaim](resource, objs)}
+		return buildPvcTable(list, filter, func(pvc v1.PersistentVolumeClaim, input string) bool { return nameContains(pvc.Name, input) })
+	case "pv":
+		list := &v1.PersistentVolumeList{Items: sortedItems[v1.PersistentVolume](resource, objs)}
+		return buildPvTable(list, filter, func(pv v1.PersistentVolume, input string) bool { return nameContains(pv.Name, input) })
+	}
+	return newOutputTable("Number", "Name")
+}
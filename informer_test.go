@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+)
+
+// 首次加载从 watch 缓存 (resourceVersion=0) 按页获取, 之后从缓存读取
+func TestInformerPaginatedList(t *testing.T) {
+	var mu sync.Mutex
+	var lists []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/namespaces/paged/pods" {
+			http.NotFound(w, r)
+			return
+		}
+		q := r.URL.Query()
+		if q.Get("watch") == "true" {
+			w.Header().Set("Content-Type", "application/json")
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+			return
+		}
+		mu.Lock()
+		lists = append(lists, "limit="+q.Get("limit")+" rv="+q.Get("resourceVersion")+" continue="+q.Get("continue"))
+		mu.Unlock()
+		list := v1.PodList{TypeMeta: metav1.TypeMeta{Kind: "PodList", APIVersion: "v1"}}
+		names := []string{"web-0", "api-1"}
+		list.ResourceVersion, list.Continue = "10", "page-2"
+		if q.Get("continue") == "page-2" {
+			names, list.ResourceVersion, list.Continue = []string{"api-0"}, "10", ""
+		}
+		for _, name := range names {
+			list.Items = append(list.Items, v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "paged", ResourceVersion: "5"}})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(list)
+	}))
+	defer server.Close()
+
+	defer func(client *kubernetes.Clientset, ns string) {
+		// 切换到其他命名空间时停止测试中启动的 informer
+		sharedInformerFactory("stopped")
+		k8sClient, *namespace = client, ns
+	}(k8sClient, *namespace)
+	var err error
+	if k8sClient, err = kubernetes.NewForConfig(&rest.Config{Host: server.URL}); err != nil {
+		t.Fatal(err)
+	}
+	*namespace = "paged"
+
+	pods, err := cachedList[v1.Pod]("pods")
+	if err != nil {
+		t.Fatalf("cachedList() error = %v", err)
+	}
+	var names []string
+	for _, p := range pods {
+		names = append(names, p.Name)
+	}
+	if want := []string{"api-0", "api-1", "web-0"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("cachedList() = %v, want %v", names, want)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"limit=500 rv=0 continue=", "limit=500 rv= continue=page-2"}
+	if !reflect.DeepEqual(lists, want) {
+		t.Errorf("list requests = %q, want %q", lists, want)
+	}
+}
+
+func TestCacheObserved(t *testing.T) {
+	informer := cache.NewSharedIndexInformer(&cache.ListWatch{}, &v1.Pod{}, 0, cache.Indexers{})
+	deleting := metav1.Now()
+	informer.GetStore().Add(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "api-0", Namespace: "dev", ResourceVersion: "7"}})
+	informer.GetStore().Add(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "api-1", Namespace: "dev", ResourceVersion: "8", DeletionTimestamp: &deleting}})
+	tests := []struct {
+		name string
+		e    cacheExpectation
+		want bool
+	}{
+		{"same version", cacheExpectation{key: "dev/api-0", resourceVersion: "7"}, true},
+		{"older version in cache", cacheExpectation{key: "dev/api-0", resourceVersion: "9"}, false},
+		{"deleted but still cached", cacheExpectation{key: "dev/api-0", deleted: true}, false},
+		{"deleted and removed", cacheExpectation{key: "dev/api-2", deleted: true}, true},
+		{"terminating", cacheExpectation{key: "dev/api-1", deleted: true}, true},
+		{"created but not cached", cacheExpectation{key: "dev/api-3", resourceVersion: "1"}, false},
+	}
+	for _, tt := range tests {
+		if got := cacheObserved(informer, tt.e); got != tt.want {
+			t.Errorf("%s: cacheObserved(%+v) = %v, want %v", tt.name, tt.e, got, tt.want)
+		}
+	}
+}
+
+func TestExpectInCache(t *testing.T) {
+	defer func() { cacheExpectations = map[string][]cacheExpectation{} }()
+	deleting := metav1.Now()
+	expectInCache(resourceRef{GVR: podsGVR, Namespace: "dev", Name: "api-0"}, &v1.Pod{ObjectMeta: metav1.ObjectMeta{ResourceVersion: "3"}})
+	expectInCache(resourceRef{GVR: podsGVR, Namespace: "dev", Name: "api-1"}, nil)
+	expectInCache(resourceRef{GVR: podsGVR, Namespace: "dev", Name: "api-2"}, &v1.Pod{ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: &deleting}})
+	expectInCache(resourceRef{GVR: pvsGVR, Name: "data"}, &v1.PersistentVolume{ObjectMeta: metav1.ObjectMeta{ResourceVersion: "4"}})
+	want := map[string][]cacheExpectation{
+		"pods": {
+			{key: "dev/api-0", resourceVersion: "3"},
+			{key: "dev/api-1", deleted: true},
+			{key: "dev/api-2", deleted: true},
+		},
+		"pv": {{key: "data", resourceVersion: "4"}},
+	}
+	if !reflect.DeepEqual(cacheExpectations, want) {
+		t.Errorf("cacheExpectations = %+v, want %+v", cacheExpectations, want)
+	}
+}
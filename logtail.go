@@ -0,0 +1,383 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"os/signal"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// 同时查看多个 pod 的日志, 类似 stern: 按标签, deployment 或名称正则选择 pod, 新的 pod 自动加入
+
+type logTailOptions struct {
+	selector labels.Selector
+	podRegex *regexp.Regexp
+	include  *regexp.Regexp
+	exclude  *regexp.Regexp
+	since    time.Duration
+}
+
+// pod 前缀使用的颜色, 按 pod 名称选择
+var logTailColors = []string{"\033[0;31m", "\033[0;32m", "\033[0;33m", "\033[0;34m", "\033[0;35m", "\033[0;36m"}
+
+var (
+	tailSelector   *string
+	tailDeployment *string
+	tailInclude    *string
+	tailExclude    *string
+	tailSince      *time.Duration
+)
+
+var tailCmd = &cobra.Command{
+	Use:   "tail [pod-regex]",
+	Short: "Tail the logs of all pods matching a label selector, deployment or name regex",
+	Args:  cobra.MaximumNArgs(1),
+	Run: cliRun(func(args []string) error {
+		podRegex := ""
+		if len(args) == 1 {
+			podRegex = args[0]
+		}
+		opts, err := newLogTailOptions(*tailSelector, *tailDeployment, podRegex, *tailInclude, *tailExclude, *tailSince)
+		if err != nil {
+			return err
+		}
+		return runLogTail(opts)
+	}),
+}
+
+func init() {
+	tailSelector = tailCmd.Flags().StringP("selector", "l", "", "label selector, e.g. app=web")
+	tailDeployment = tailCmd.Flags().String("deploy", "", "tail the pods of this deployment")
+	tailInclude = tailCmd.Flags().String("include", "", "only print lines matching this regex")
+	tailExclude = tailCmd.Flags().String("exclude", "", "do not print lines matching this regex")
+	tailSince = tailCmd.Flags().Duration("since", 0, "only print logs newer than this duration, e.g. 10m")
+	rootCmd.AddCommand(tailCmd)
+}
+
+func newLogTailOptions(selector, deployment, podRegex, include, exclude string, since time.Duration) (logTailOptions, error) {
+	opts := logTailOptions{selector: labels.Everything(), since: since}
+	var err error
+	if selector != "" {
+		if opts.selector, err = labels.Parse(selector); err != nil {
+			return opts, fmt.Errorf("invalid label selector %q: %v", selector, err)
+		}
+	}
+	if deployment != "" {
+		ctx, cancel := requestContext()
+		defer cancel()
+		d, err := k8sClient.AppsV1().Deployments(*namespace).Get(ctx, deployment, metav1.GetOptions{})
+		if err != nil {
+			return opts, fmt.Errorf("Error getting deployment %s: %v", deployment, requestError(ctx, err))
+		}
+		deploySelector, err := metav1.LabelSelectorAsSelector(d.Spec.Selector)
+		if err != nil {
+			return opts, fmt.Errorf("invalid selector of deployment %s: %v", deployment, err)
+		}
+		requirements, _ := deploySelector.Requirements()
+		opts.selector = opts.selector.Add(requirements...)
+	}
+	for _, r := range []struct {
+		expr string
+		dest **regexp.Regexp
+		name string
+	}{{podRegex, &opts.podRegex, "pod"}, {include, &opts.include, "include"}, {exclude, &opts.exclude, "exclude"}} {
+		if r.expr == "" {
+			continue
+		}
+		if *r.dest, err = regexp.Compile(r.expr); err != nil {
+			return opts, fmt.Errorf("invalid %s regex %q: %v", r.name, r.expr, err)
+		}
+	}
+	return opts, nil
+}
+
+func (o logTailOptions) matchPod(pod *v1.Pod) bool {
+	if !o.selector.Matches(labels.Set(pod.Labels)) {
+		return false
+	}
+	return o.podRegex == nil || o.podRegex.MatchString(pod.Name)
+}
+
+func (o logTailOptions) matchLine(line string) bool {
+	if o.include != nil && !o.include.MatchString(line) {
+		return false
+	}
+	return o.exclude == nil || !o.exclude.MatchString(line)
+}
+
+func logTailColor(name string) string {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return logTailColors[h.Sum32()%uint32(len(logTailColors))]
+}
+
+// 交互模式中选择要查看日志的 pod
+func handleLogTailAction() {
+	by := ""
+	if err := survey.AskOne(&survey.Select{
+		Message: "tail logs of pods selected by:",
+		Options: []string{"label selector", "deployment", "name regex", "exit"},
+	}, &by); err != nil || by == "exit" {
+		return
+	}
+	value := ""
+	survey.AskOne(&survey.Input{Message: fmt.Sprintf("Enter %s: ", by)}, &value)
+	var include, exclude, since string
+	survey.AskOne(&survey.Input{Message: "Only lines matching regex (empty for all): "}, &include)
+	survey.AskOne(&survey.Input{Message: "Skip lines matching regex (empty for none): "}, &exclude)
+	survey.AskOne(&survey.Input{Message: "Logs since (e.g. 10m, empty for all): "}, &since)
+
+	var sinceDuration time.Duration
+	if since = strings.TrimSpace(since); since != "" {
+		var err error
+		if sinceDuration, err = time.ParseDuration(since); err != nil {
+			fmt.Printf("invalid duration %q: %v\n", since, err)
+			return
+		}
+	}
+	var selector, deployment, podRegex string
+	switch by {
+	case "label selector":
+		selector = value
+	case "deployment":
+		deployment = value
+	case "name regex":
+		podRegex = value
+	}
+	opts, err := newLogTailOptions(selector, deployment, podRegex, include, exclude, sinceDuration)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if err := runLogTail(opts); err != nil {
+		fmt.Println(err)
+	}
+}
+
+// 查看所有匹配的 pod 和容器的日志, 直到 Ctrl+C
+func runLogTail(opts logTailOptions) error {
+	informer, err := resourceInformer(*namespace, "pods")
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	t := &logTail{opts: opts, ctx: ctx, pods: informer.GetStore(), tailing: map[string]bool{}}
+	registration, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { t.update(obj) },
+		UpdateFunc: func(oldObj, newObj interface{}) { t.update(newObj) },
+	})
+	if err != nil {
+		return err
+	}
+	defer informer.RemoveEventHandler(registration)
+	cache.WaitForCacheSync(ctx.Done(), registration.HasSynced)
+
+	t.mu.Lock()
+	t.synced = true
+	count := len(t.tailing)
+	t.mu.Unlock()
+	fmt.Printf("Tailing %d containers in namespace %s, new pods are added automatically, press Ctrl+C to stop\n", count, *namespace)
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt)
+	defer signal.Stop(sigChan)
+	<-sigChan
+	cancel()
+	t.wg.Wait()
+	fmt.Println()
+	return nil
+}
+
+type logTail struct {
+	opts logTailOptions
+	ctx  context.Context
+	pods cache.Store
+	wg   sync.WaitGroup
+
+	mu sync.Mutex
+	// 缓存同步之前收到的 pod 是开始时已有的 pod, 只有它们使用 --since
+	synced bool
+	// 已经开始查看的容器实例, key 为 pod uid/容器名/重启次数, 容器重启后重新查看
+	tailing map[string]bool
+}
+
+func (t *logTail) update(obj interface{}) {
+	pod, ok := obj.(*v1.Pod)
+	if !ok || !t.opts.matchPod(pod) {
+		return
+	}
+	for _, status := range podContainerStatuses(pod) {
+		if status.State.Running == nil && status.State.Terminated == nil {
+			continue
+		}
+		key := fmt.Sprintf("%s/%s/%d", pod.UID, status.Name, status.RestartCount)
+		t.mu.Lock()
+		if t.ctx.Err() != nil || t.tailing[key] {
+			t.mu.Unlock()
+			continue
+		}
+		t.tailing[key] = true
+		existing := !t.synced
+		t.wg.Add(1)
+		t.mu.Unlock()
+		go t.tail(pod, status.Name, status.RestartCount, existing)
+	}
+}
+
+func (t *logTail) tail(pod *v1.Pod, container string, restartCount int32, existing bool) {
+	defer t.wg.Done()
+	color := logTailColor(pod.Name)
+	prefix := fmt.Sprintf("%s%s\033[0m \033[2m%s\033[0m", color, pod.Name, container)
+	t.print(fmt.Sprintf("%s+\033[0m %s", color, prefix))
+
+	// 带上时间戳, 连接断开后从最后一行的时间继续
+	options := &v1.PodLogOptions{Container: container, Follow: true, Timestamps: true}
+	// 开始时已有的 pod 只输出 --since 之内的日志, 之后出现的容器输出全部日志
+	if t.opts.since > 0 && existing {
+		seconds := int64(t.opts.since.Seconds())
+		options.SinceSeconds = &seconds
+	}
+	var last logPosition
+	var skip func(time.Time, string) bool
+	for {
+		err := t.stream(pod.Name, options, prefix, &last, skip)
+		if t.ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			t.print(fmt.Sprintf("%s \033[1;31m%v\033[0m", prefix, err))
+		}
+		select {
+		case <-t.ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+		if !t.containerRunning(pod, container, restartCount) {
+			break
+		}
+		// 容器仍在运行, 连接被 API server 或 kubelet 断开
+		t.print(fmt.Sprintf("%s \033[2mlog stream closed, reconnecting\033[0m", prefix))
+		if !last.time.IsZero() {
+			options.SinceSeconds = nil
+			options.SinceTime = &metav1.Time{Time: last.time}
+			skip = last.resumeFilter()
+		}
+	}
+	t.print(fmt.Sprintf("%s-\033[0m %s", color, prefix))
+}
+
+// 输出一次日志流并记录读到的位置, 重新连接时 skip 跳过已经输出过的行
+func (t *logTail) stream(pod string, options *v1.PodLogOptions, prefix string, last *logPosition, skip func(time.Time, string) bool) error {
+	stream, err := k8sClient.CoreV1().Pods(*namespace).GetLogs(pod, options).Stream(t.ctx)
+	if err != nil {
+		return fmt.Errorf("Error getting logs: %v", err)
+	}
+	defer stream.Close()
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		text := scanner.Text()
+		if timestamp, line, ok := splitLogTimestamp(text); ok {
+			if skip != nil && skip(timestamp, line) {
+				continue
+			}
+			last.add(timestamp, line)
+			text = line
+		}
+		if t.opts.matchLine(text) {
+			t.print(prefix + " " + text)
+		}
+	}
+	if err := scanner.Err(); err != nil && t.ctx.Err() == nil {
+		return fmt.Errorf("Error reading logs: %v", err)
+	}
+	return nil
+}
+
+// 容器的同一个实例是否仍在运行
+func (t *logTail) containerRunning(pod *v1.Pod, container string, restartCount int32) bool {
+	obj, exists, err := t.pods.Get(pod)
+	if err != nil || !exists {
+		return false
+	}
+	current, ok := obj.(*v1.Pod)
+	if !ok || current.UID != pod.UID {
+		return false
+	}
+	for _, status := range podContainerStatuses(current) {
+		if status.Name == container {
+			return status.RestartCount == restartCount && status.State.Running != nil
+		}
+	}
+	return false
+}
+
+// init 容器和普通容器的状态
+func podContainerStatuses(pod *v1.Pod) []v1.ContainerStatus {
+	return append(append([]v1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+}
+
+// 日志流中读到的最后位置: 最后一行的时间和这个时间的所有行
+type logPosition struct {
+	time  time.Time
+	lines []string
+}
+
+func (p *logPosition) add(timestamp time.Time, line string) {
+	if !timestamp.Equal(p.time) {
+		p.time, p.lines = timestamp, nil
+	}
+	p.lines = append(p.lines, line)
+}
+
+// SinceTime 只精确到秒, 重新连接后会再次收到已经输出过的行:
+// 跳过时间更早的行, 以及时间相同且内容已经输出过的行
+func (p *logPosition) resumeFilter() func(time.Time, string) bool {
+	since := p.time
+	seen := map[string]int{}
+	for _, l := range p.lines {
+		seen[l]++
+	}
+	return func(timestamp time.Time, line string) bool {
+		if timestamp.Before(since) {
+			return true
+		}
+		if timestamp.Equal(since) && seen[line] > 0 {
+			seen[line]--
+			return true
+		}
+		return false
+	}
+}
+
+// 分离 Timestamps 为 true 时每行开头的 RFC3339Nano 时间
+func splitLogTimestamp(text string) (time.Time, string, bool) {
+	timestamp, line, _ := strings.Cut(text, " ")
+	parsed, err := time.Parse(time.RFC3339Nano, timestamp)
+	if err != nil {
+		return time.Time{}, text, false
+	}
+	return parsed, line, true
+}
+
+// 多个容器的日志按行输出, 避免交错
+func (t *logTail) print(text string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fmt.Println(text)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogTailOptionsMatchLine(t *testing.T) {
+	tests := []struct {
+		include string
+		exclude string
+		line    string
+		want    bool
+	}{
+		{"", "", "GET /healthz 200", true},
+		{"error|warn", "", "level=error msg=failed", true},
+		{"error|warn", "", "level=info msg=ok", false},
+		{"", "healthz", "GET /healthz 200", false},
+		{"", "healthz", "GET /api 200", true},
+		{"GET", "healthz", "GET /healthz 200", false},
+		{"GET", "healthz", "GET /api 500", true},
+	}
+	for _, tt := range tests {
+		opts, err := newLogTailOptions("", "", "", tt.include, tt.exclude, 0)
+		if err != nil {
+			t.Fatalf("newLogTailOptions(%q, %q) error = %v", tt.include, tt.exclude, err)
+		}
+		if got := opts.matchLine(tt.line); got != tt.want {
+			t.Errorf("include %q exclude %q: matchLine(%q) = %v, want %v", tt.include, tt.exclude, tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestSplitLogTimestamp(t *testing.T) {
+	tests := []struct {
+		text     string
+		wantTime time.Time
+		wantLine string
+		wantOK   bool
+	}{
+		{"2024-05-01T10:00:00.123456789Z hello world", time.Date(2024, 5, 1, 10, 0, 0, 123456789, time.UTC), "hello world", true},
+		{"2024-05-01T10:00:01Z ", time.Date(2024, 5, 1, 10, 0, 1, 0, time.UTC), "", true},
+		{"2024-05-01T10:00:02Z", time.Date(2024, 5, 1, 10, 0, 2, 0, time.UTC), "", true},
+		{"hello world", time.Time{}, "hello world", false},
+		{"", time.Time{}, "", false},
+	}
+	for _, tt := range tests {
+		gotTime, gotLine, gotOK := splitLogTimestamp(tt.text)
+		if !gotTime.Equal(tt.wantTime) || gotLine != tt.wantLine || gotOK != tt.wantOK {
+			t.Errorf("splitLogTimestamp(%q) = %v, %q, %v, want %v, %q, %v", tt.text, gotTime, gotLine, gotOK, tt.wantTime, tt.wantLine, tt.wantOK)
+		}
+	}
+}
+
+// 重新连接后只跳过已经输出过的行, 同一时间的新行和重复内容的行照常输出
+func TestLogPositionResumeFilter(t *testing.T) {
+	t0 := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	t1 := t0.Add(500 * time.Millisecond)
+	var pos logPosition
+	for _, l := range []struct {
+		at   time.Time
+		line string
+	}{{t0, "start"}, {t1, "tick"}, {t1, "tick"}} {
+		pos.add(l.at, l.line)
+	}
+
+	// 从 t0 所在的秒重新发送
+	skip := pos.resumeFilter()
+	var printed []string
+	for _, l := range []struct {
+		at   time.Time
+		line string
+	}{{t0, "start"}, {t1, "tick"}, {t1, "tick"}, {t1, "tick"}, {t1, "done"}, {t1.Add(time.Millisecond), "next"}} {
+		if !skip(l.at, l.line) {
+			printed = append(printed, l.line)
+		}
+	}
+	if want := []string{"tick", "done", "next"}; strings.Join(printed, ",") != strings.Join(want, ",") {
+		t.Errorf("printed after reconnect = %q, want %q", printed, want)
+	}
+}
@@ -2,14 +2,13 @@ package main
 
 import (
 	"bytes"
-	"context"
 	"encoding/json"
 	"fmt"
-	"math/rand"
 	"os"
 	"os/exec"
 	"os/signal"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"syscall"
@@ -21,10 +20,11 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 
-	"github.com/olekukonko/tablewriter"
 
 	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/utils/ptr"
@@ -33,10 +33,13 @@ import (
 var (
 	line       = liner.NewLiner()
 	kubeConfig *string
+	configName *string
 	namespace  *string = new(string)
 	k8sClient  *kubernetes.Clientset
 	version    = "V0.0.1"
 	buildTime  = "unknown"
+	// 当前使用的 .kube-ui 配置项, 使用 --kubeconfig 指定 kubeconfig 时按路径匹配
+	currentConfig KubeConfig
 )
 
 // 修改配置结构体
@@ -47,6 +50,20 @@ type KubeConfig struct {
 	Comment         string `json:"comment"`
 	ImagePullSecret string `json:"imagePullSecret,omitempty"` // 新增：镜像拉取密钥
 	TunnelImage     string `json:"tunnelImage,omitempty"`     // 新增：tunnel使用的镜像
+	ProxyImage      string `json:"proxyImage,omitempty"`      // SOCKS5/HTTP 代理使用的镜像
+	RelayImage      string `json:"relayImage,omitempty"`      // UDP 中继使用的镜像, 需要 python3
+	// tunnel pod 的调度和准入配置: nodeSelector, tolerations, serviceAccountName 等
+	TunnelPod *TunnelPodConfig `json:"tunnelPod,omitempty"`
+	// 常用的隧道, 可以在 tunnel 菜单中选择或通过 kube-ui tunnel <name> 启动
+	Tunnels []TunnelTemplate `json:"tunnels,omitempty"`
+	// 等待 tunnel pod 就绪和检查目标连通性的超时时间, 默认 120 秒
+	TunnelTimeoutSeconds int `json:"tunnelTimeoutSeconds,omitempty"`
+	// API 请求的超时时间, 默认 30 秒
+	RequestTimeoutSeconds int `json:"requestTimeoutSeconds,omitempty"`
+	// 每个资源列表的默认排序, 例如 {"pods": "-restarts", "pvc": "-capacity"}
+	Sort map[string]string `json:"sort,omitempty"`
+	// 交互模式中列表最多显示的行数, 默认不限制, 超出时提示搜索缩小范围
+	ListLimit int `json:"listLimit,omitempty"`
 }
 
 type KubeUIConfig struct {
@@ -57,7 +74,15 @@ var rootCmd = &cobra.Command{
 	Use:   "kube-ui",
 	Short: "A Kubernetes CLI UI tool",
 	Long:  `kube-ui is a CLI tool that provides an interactive interface for managing Kubernetes resources`,
+	// 所有命令共用的参数检查
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return validateOutputFormat(*outputFormat)
+	},
 	Run: func(cmd *cobra.Command, args []string) {
+		if *tuiMode {
+			runTUI()
+			return
+		}
 		runMain()
 	},
 }
@@ -100,7 +125,11 @@ var configCmd = &cobra.Command{
 			return
 		}
 
-		fmt.Printf("Configuration file: %s\n\n", kubeUIPath)
+		fmt.Printf("Configuration file: %s\n", kubeUIPath)
+		if logPath, err := auditLogPath(); err == nil {
+			fmt.Printf("Audit log: %s\n", logPath)
+		}
+		fmt.Println()
 		fmt.Println(prettyJSON.String())
 	},
 }
@@ -108,6 +137,7 @@ var configCmd = &cobra.Command{
 func init() {
 	kubeConfig = rootCmd.PersistentFlags().StringP("kubeconfig", "f", "", "absolute path to the kubeconfig file")
 	namespace = rootCmd.PersistentFlags().StringP("namespace", "n", "", "k8s namespace to use")
+	configName = rootCmd.PersistentFlags().String("config", "", "name of the ~/.kube-ui config entry to use, skips the config prompt")
 
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(configCmd)
@@ -122,65 +152,28 @@ func main() {
 
 func runMain() {
 	defer line.Close()
-	line.SetCtrlCAborts(true)
-
-	// 如果未指定 kubeconfig，尝试读取 ~/.kube-ui
-	if *kubeConfig == "" {
-		if err := loadKubeUIConfig(); err != nil {
-			fmt.Printf("Error loading kube-ui config: %v\n", err)
-			return
-		}
-	}
 
-	// 配置文件不能为空
-	if *kubeConfig == "" {
-		fmt.Println("Kubeconfig file is required")
-		return
-	}
-
-	// 使用配置文件创建k8s客户端
-	config, err := clientcmd.BuildConfigFromFlags("", *kubeConfig)
-	if err != nil {
-		fmt.Printf("Error building kubeconfig: %v\n", err)
-		return
-	}
-
-	k8sClient, err = kubernetes.NewForConfig(config)
-	if err != nil {
-		fmt.Printf("Error creating Kubernetes client: %v\n", err)
+	if err := initKubeClient(); err != nil {
+		fmt.Println(err)
 		return
 	}
+	tableRowLimit = listLimit()
+	numberedOutput = true
+	validateConfigSorts()
 
 	for {
 		if *namespace == "" {
-			//获取k8s命名空间
-			namespaces, err := k8sClient.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{})
-			if err != nil {
-				fmt.Printf("Error listing namespaces: %v\n", err)
-				fmt.Printf("Failed to get namespace list, please check if you have permission, you can manually specify the namespace")
-				return
-			}
-			var namespaceList = make([]string, 0)
-			for _, space := range namespaces.Items {
-				//fmt.Printf("[\u001B[1;31m %d \u001B[0m] %s\n", i, namespace.Name)
-				namespaceList = append(namespaceList, space.Name)
-			}
-			prompt := &survey.Select{
-				Message: "choose k8s namespace:",
-				Options: namespaceList,
-			}
-			err = survey.AskOne(prompt, namespace)
-			if err != nil {
-				fmt.Printf("Error selecting namespace: %v\n", err)
+			if err := selectNamespace(); err != nil {
+				fmt.Println(err)
 				return
 			}
 		}
 		var action = new(string)
 		prompt := &survey.Select{
 			Message: fmt.Sprintf("choose action in namespace %s:", *namespace),
-			Options: []string{"k9s", "pods", "deployments", "svc", "pvc", "pv", "configmap", "tunnel", "exit"},
+			Options: []string{"k9s", "pods", "deployments", "svc", "pvc", "pv", "configmap", "tunnel", "history", "output", "exit"},
 		}
-		err = survey.AskOne(prompt, action)
+		err := survey.AskOne(prompt, action)
 		if err != nil {
 			fmt.Printf("Error selecting action: %v\n", err)
 			os.Exit(1)
@@ -213,7 +206,9 @@ func runMain() {
 			}
 
 			// 等待进程完成
-			if err := cmd.Wait(); err != nil {
+			err := cmd.Wait()
+			auditCommand("k9s", arg, err)
+			if err != nil {
 				// 某些退出码可能是正常的，如用户按Ctrl+C退出
 				fmt.Printf("k9s process exited: %v\n", err)
 				os.Exit(0)
@@ -232,6 +227,10 @@ func runMain() {
 			handleNamespacePvAction()
 		case "tunnel":
 			handleTunnelAction()
+		case "history":
+			handleHistoryAction()
+		case "output":
+			handleOutputAction()
 		default:
 			shouldReturn := checkExitCode(*action)
 			if shouldReturn {
@@ -244,9 +243,38 @@ func runMain() {
 
 }
 
+// 选择命名空间
+func selectNamespace() error {
+	//获取k8s命名空间
+	ctx, cancel := requestContext()
+	defer cancel()
+	namespaces, err := k8sClient.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		err = requestError(ctx, err)
+		fmt.Printf("Error listing namespaces: %v\n", err)
+		return fmt.Errorf("Failed to get namespace list, please check if you have permission, you can manually specify the namespace")
+	}
+	var namespaceList = make([]string, 0)
+	for _, space := range namespaces.Items {
+		namespaceList = append(namespaceList, space.Name)
+	}
+	prompt := &survey.Select{
+		Message: "choose k8s namespace:",
+		Options: namespaceList,
+	}
+	if *namespace != "" {
+		prompt.Default = *namespace
+	}
+	if err := survey.AskOne(prompt, namespace); err != nil {
+		return fmt.Errorf("Error selecting namespace: %v", err)
+	}
+	return nil
+}
+
 func handleNamespacePvAction() {
 	// 获取Pv列表
-	pvList, err := k8sClient.CoreV1().PersistentVolumes().List(context.TODO(), metav1.ListOptions{})
+	items, err := cachedList[v1.PersistentVolume]("pv")
+	pvList := &v1.PersistentVolumeList{Items: items}
 	if err != nil {
 		fmt.Printf("Error listing pvcs: %v\n", err)
 		fmt.Printf("Failed to get the Pvc list under namespace %s", *namespace)
@@ -255,26 +283,59 @@ func handleNamespacePvAction() {
 	// 打印Pv列表
 	fmt.Println("Pvs in namespace", *namespace)
 	printPvTable(pvList, "", nil)
+	filter := ""
 	for {
 		input := ""
 		prompt := &survey.Input{
-			Message: "Enter pv number or search, exit to quit: ",
+			Message: "Enter pv number, name or search; 1,3-5, all or status=X for bulk actions; sort <column>, watch, exit: ",
 		}
 		survey.AskOne(prompt, &input)
 
-		// 	// 检查输入是否为数字
-		pvNumber, err := strconv.Atoi(input)
-		if err == nil && pvNumber >= 0 && pvNumber < len(pvList.Items) {
-			selectedPv := pvList.Items[pvNumber]
-			handlePvAction(line, selectedPv)
-			pvList, _ = k8sClient.CoreV1().PersistentVolumes().List(context.TODO(), metav1.ListOptions{})
+		if input == "watch" {
+			watchResourceTable("pv")
+			pvList.Items, _ = cachedList[v1.PersistentVolume]("pv")
 			printPvTable(pvList, "", nil)
-		} else {
-			//如果== exit 退出
-			shouldReturn := checkExitCode(input)
-			if shouldReturn {
-				return
+			filter = ""
+			continue
+		}
+		if input == "sort" || strings.HasPrefix(input, "sort ") {
+			handleSortCommand[v1.PersistentVolume]("pv", input)
+			pvList.Items, _ = cachedList[v1.PersistentVolume]("pv")
+			printPvTable(pvList, "", nil)
+			filter = ""
+			continue
+		}
+		//如果== exit 退出
+		if checkExitCode(input) {
+			return
+		}
+		// 批量选择: 3-7, 1,4,9, all 或 status=...
+		if isBulkSelection(input) {
+			selected, err := selectBulkItems(pvList.Items, input, filter, func(item v1.PersistentVolume) string { return item.Name }, func(item v1.PersistentVolume) []string { return []string{string(item.Status.Phase)} })
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+			handleBulkAction("pv", newBulkTargets("pv", selected))
+			pvList.Items, _ = cachedList[v1.PersistentVolume]("pv")
+			printPvTable(pvList, "", nil)
+			filter = ""
+			continue
+		}
+		// 按编号, 名称或唯一的名称前缀选择, 操作前确认对象没有被删除或替换
+		if selectedPv, ok := selectListItem(pvList.Items, input, func(item v1.PersistentVolume) string { return item.Name }); ok {
+			live, err := resolveSelected[v1.PersistentVolume](pvRef(selectedPv.Name), selectedPv.UID, selectedPv.ResourceVersion)
+			if err != nil {
+				fmt.Println(err)
+			} else {
+				handlePvAction(line, *live)
+				expectLiveInCache(pvRef(selectedPv.Name))
 			}
+			pvList.Items, _ = cachedList[v1.PersistentVolume]("pv")
+			printPvTable(pvList, "", nil)
+			filter = ""
+		} else {
+			filter = input
 			printPvTable(pvList, input, func(pv v1.PersistentVolume, input string) bool {
 				return strings.Contains(pv.Name, input)
 			})
@@ -288,8 +349,10 @@ func handlePvAction(line *liner.State, selectedPv v1.PersistentVolume) {
 		// 高亮显示选中的Pv名称
 		fmt.Printf("Selected Pv: \033[1;33m %s \033[0m \n", selectedPv.Name)
 		fmt.Println("====================================")
-		fmt.Println("command action [p, exit]: ")
+		fmt.Println("command action [p, e, r, exit]: ")
 		fmt.Println("\u001B[0;31m p \u001B[0m: print Pv info")
+		fmt.Println("\u001B[0;31m e \u001B[0m: edit Pv")
+		fmt.Println("\u001B[0;31m r \u001B[0m: restore Pv from local snapshot")
 		fmt.Println("\u001B[0;31m exit \u001B[0m: quit current action")
 
 		action, _ := line.Prompt("Enter action: ")
@@ -304,6 +367,10 @@ func handlePvAction(line *liner.State, selectedPv v1.PersistentVolume) {
 			// describe pv
 			fmt.Println("==============describe pv======================")
 			execCommand("describe", "pv", selectedPv.Name)
+		case "e":
+			handleEditAction(pvRef(selectedPv.Name))
+		case "r":
+			handleRestoreAction(pvRef(selectedPv.Name))
 		default:
 			shouldReturn := checkExitCode(action)
 			if shouldReturn {
@@ -315,17 +382,32 @@ func handlePvAction(line *liner.State, selectedPv v1.PersistentVolume) {
 }
 
 func printPvTable(pvList *v1.PersistentVolumeList, s string, f func(pv v1.PersistentVolume, input string) bool) {
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"Number", "Name", "Status", "StorageClass", "Capacity"})
+	buildPvTable(pvList, s, f).render()
+}
+
+func buildPvTable(pvList *v1.PersistentVolumeList, s string, f func(pv v1.PersistentVolume, input string) bool) *outputTable {
+	table := newOutputTable("Number", "Name", "Status", "StorageClass", "Capacity").
+		wide("Claim", "ReclaimPolicy").
+		forKind("v1", "PersistentVolume")
+	table.total = len(pvList.Items)
 	for i, pv := range pvList.Items {
-		table.Append([]string{fmt.Sprintf("%d", i), pv.Name, string(pv.Status.Phase), pv.Spec.StorageClassName, pv.Spec.Capacity.Storage().String()})
+		if f != nil && !f(pv, s) {
+			continue
+		}
+		claim := ""
+		if pv.Spec.ClaimRef != nil {
+			claim = pv.Spec.ClaimRef.Namespace + "/" + pv.Spec.ClaimRef.Name
+		}
+		table.append(&pvList.Items[i], fmt.Sprintf("%d", i), pv.Name, string(pv.Status.Phase), pv.Spec.StorageClassName, pv.Spec.Capacity.Storage().String(),
+			claim, string(pv.Spec.PersistentVolumeReclaimPolicy))
 	}
-	table.Render()
+	return table
 }
 
 func handleNamespaceDeploymentAction() {
 	// 获取Deployment列表
-	deployments, err := k8sClient.AppsV1().Deployments(*namespace).List(context.TODO(), metav1.ListOptions{})
+	items, err := cachedList[appsv1.Deployment]("deployments")
+	deployments := &appsv1.DeploymentList{Items: items}
 	if err != nil {
 		fmt.Printf("Error listing deployments: %v\n", err)
 		fmt.Printf("Failed to get the Deployment list under namespace %s", *namespace)
@@ -334,26 +416,59 @@ func handleNamespaceDeploymentAction() {
 	// 打印Deployment列表
 	fmt.Println("Deployments in namespace", *namespace)
 	printDeploymentTable(deployments, "", nil)
+	filter := ""
 	for {
 		input := ""
 		prompt := &survey.Input{
-			Message: "Enter deployment number or search, exit to quit: ",
+			Message: "Enter deployment number, name or search; 1,3-5, all or status=X for bulk actions; sort <column>, watch, exit: ",
 		}
 		survey.AskOne(prompt, &input)
 
-		// 	// 检查输入是否为数字
-		podNumber, err := strconv.Atoi(input)
-		if err == nil && podNumber >= 0 && podNumber < len(deployments.Items) {
-			selectedDeployment := deployments.Items[podNumber]
-			handleDeploymentAction(line, selectedDeployment)
-			deployments, _ = k8sClient.AppsV1().Deployments(*namespace).List(context.TODO(), metav1.ListOptions{})
+		if input == "watch" {
+			watchResourceTable("deployments")
+			deployments.Items, _ = cachedList[appsv1.Deployment]("deployments")
 			printDeploymentTable(deployments, "", nil)
-		} else {
-			//如果== exit 退出
-			shouldReturn := checkExitCode(input)
-			if shouldReturn {
-				return
+			filter = ""
+			continue
+		}
+		if input == "sort" || strings.HasPrefix(input, "sort ") {
+			handleSortCommand[appsv1.Deployment]("deployments", input)
+			deployments.Items, _ = cachedList[appsv1.Deployment]("deployments")
+			printDeploymentTable(deployments, "", nil)
+			filter = ""
+			continue
+		}
+		//如果== exit 退出
+		if checkExitCode(input) {
+			return
+		}
+		// 批量选择: 3-7, 1,4,9, all 或 status=...
+		if isBulkSelection(input) {
+			selected, err := selectBulkItems(deployments.Items, input, filter, func(item appsv1.Deployment) string { return item.Name }, deploymentStatuses)
+			if err != nil {
+				fmt.Println(err)
+				continue
 			}
+			handleBulkAction("deployments", newBulkTargets("deployments", selected))
+			deployments.Items, _ = cachedList[appsv1.Deployment]("deployments")
+			printDeploymentTable(deployments, "", nil)
+			filter = ""
+			continue
+		}
+		// 按编号, 名称或唯一的名称前缀选择, 操作前确认对象没有被删除或替换
+		if selectedDeployment, ok := selectListItem(deployments.Items, input, func(item appsv1.Deployment) string { return item.Name }); ok {
+			live, err := resolveSelected[appsv1.Deployment](deploymentRef(selectedDeployment.Name), selectedDeployment.UID, selectedDeployment.ResourceVersion)
+			if err != nil {
+				fmt.Println(err)
+			} else {
+				handleDeploymentAction(line, *live)
+				expectLiveInCache(deploymentRef(selectedDeployment.Name))
+			}
+			deployments.Items, _ = cachedList[appsv1.Deployment]("deployments")
+			printDeploymentTable(deployments, "", nil)
+			filter = ""
+		} else {
+			filter = input
 			printDeploymentTable(deployments, input, func(deployment appsv1.Deployment, input string) bool {
 				return strings.Contains(deployment.Name, input)
 			})
@@ -367,9 +482,12 @@ func handleDeploymentAction(line *liner.State, selectedDeployment appsv1.Deploym
 		// 高亮显示选中的Deployment名称
 		fmt.Printf("Selected Deployment: \033[1;33m %s \033[0m \n", selectedDeployment.Name)
 		fmt.Println("====================================")
-		fmt.Println("command action [p, s, exit]: ")
+		fmt.Println("command action [p, s, e, r, l, exit]: ")
 		fmt.Println("\u001B[0;31m p \u001B[0m: print Deployment info")
 		fmt.Println("\u001B[0;31m s \u001B[0m: scale Deployment")
+		fmt.Println("\u001B[0;31m e \u001B[0m: edit Deployment")
+		fmt.Println("\u001B[0;31m r \u001B[0m: restore Deployment from local snapshot")
+		fmt.Println("\u001B[0;31m l \u001B[0m: tail logs of all Deployment pods")
 		fmt.Println("\u001B[0;31m exit \u001B[0m: quit current action")
 
 		action, _ := line.Prompt("Enter action: ")
@@ -380,6 +498,18 @@ func handleDeploymentAction(line *liner.State, selectedDeployment appsv1.Deploym
 			execCommand("get", "deployment", selectedDeployment.Name, "-o", "yaml")
 		case "s":
 			handleDeploymentScaleNumAction(line, selectedDeployment)
+		case "e":
+			handleEditAction(deploymentRef(selectedDeployment.Name))
+		case "r":
+			handleRestoreAction(deploymentRef(selectedDeployment.Name))
+		case "l":
+			opts, err := newLogTailOptions("", selectedDeployment.Name, "", "", "", 0)
+			if err == nil {
+				err = runLogTail(opts)
+			}
+			if err != nil {
+				fmt.Println(err)
+			}
 		default:
 			shouldReturn := checkExitCode(action)
 			if shouldReturn {
@@ -393,16 +523,75 @@ func handleDeploymentAction(line *liner.State, selectedDeployment appsv1.Deploym
 func handleDeploymentScaleNumAction(line *liner.State, selectedDeployment appsv1.Deployment) {
 	// 设置Deployment的副本数
 	scaleNum, _ := line.Prompt("Enter the number of replicas: ")
-	execCommand("scale", "deployment", selectedDeployment.Name, "--replicas="+scaleNum)
+	replicas, err := strconv.Atoi(strings.TrimSpace(scaleNum))
+	if err != nil || replicas < 0 {
+		fmt.Printf("Invalid number of replicas %q\n", scaleNum)
+		return
+	}
+	scaleDeployment(selectedDeployment.Name, replicas)
+}
+
+// 修改 Deployment 副本数, 修改前先备份
+func scaleDeployment(name string, replicas int) error {
+	if !backupBeforeChange(deploymentRef(name), "scale") {
+		return fmt.Errorf("scale cancelled")
+	}
+	return execCommand("scale", "deployment", name, fmt.Sprintf("--replicas=%d", replicas))
 }
 
 func printDeploymentTable(deployments *appsv1.DeploymentList, s string, f func(deployment appsv1.Deployment, input string) bool) {
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"Number", "Name", "Replicas", "Age"})
+	buildDeploymentTable(deployments, s, f).render()
+}
+
+func buildDeploymentTable(deployments *appsv1.DeploymentList, s string, f func(deployment appsv1.Deployment, input string) bool) *outputTable {
+	table := newOutputTable("Number", "Name", "Replicas", "Age").
+		wide("Containers", "Images").
+		forKind("apps/v1", "Deployment")
+	table.total = len(deployments.Items)
 	for i, deployment := range deployments.Items {
-		table.Append([]string{fmt.Sprintf("%d", i), deployment.Name, fmt.Sprintf("%d/%d", deployment.Status.Replicas, deployment.Status.Replicas), deployment.CreationTimestamp.Format(time.RFC3339)})
+		var containers, images []string
+		for _, c := range deployment.Spec.Template.Spec.Containers {
+			containers = append(containers, c.Name)
+			images = append(images, c.Image)
+		}
+		table.append(&deployments.Items[i], fmt.Sprintf("%d", i), deployment.Name, fmt.Sprintf("%d/%d", deployment.Status.Replicas, deployment.Status.Replicas), deployment.CreationTimestamp.Format(time.RFC3339),
+			strings.Join(containers, ","), strings.Join(images, ","))
+	}
+	return table
+}
+
+// 选择配置并创建k8s客户端, 子命令和交互模式共用
+func initKubeClient() error {
+	// 如果未指定 kubeconfig，尝试读取 ~/.kube-ui
+	if *kubeConfig == "" {
+		if err := loadKubeUIConfig(); err != nil {
+			return fmt.Errorf("Error loading kube-ui config: %v", err)
+		}
+	}
+
+	// 配置文件不能为空
+	if *kubeConfig == "" {
+		return fmt.Errorf("Kubeconfig file is required")
+	}
+
+	// 使用配置文件创建k8s客户端
+	config, err := clientcmd.BuildConfigFromFlags("", *kubeConfig)
+	if err != nil {
+		return fmt.Errorf("Error building kubeconfig: %v", err)
+	}
+	if currentConfig.Path == "" {
+		currentConfig = findKubeUIConfigByPath(*kubeConfig)
+	}
+	initAuditInfo(*kubeConfig)
+
+	k8sClient, err = kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("Error creating Kubernetes client: %v", err)
 	}
-	table.Render()
+	if err := initDynamicClient(config); err != nil {
+		return fmt.Errorf("Error creating Kubernetes client: %v", err)
+	}
+	return checkAPIServer(config.Host)
 }
 
 // 加载kubeconfig配置
@@ -415,14 +604,20 @@ func loadKubeUIConfig() error {
 	kubeUIPath := filepath.Join(homeDir, ".kube-ui")
 	if _, err := os.Stat(kubeUIPath); err == nil {
 		// 读取并解析 .kube-ui 文件
-		data, err := os.ReadFile(kubeUIPath)
+		config, err := readKubeUIConfig()
 		if err != nil {
-			return fmt.Errorf("error reading .kube-ui file: %v", err)
+			return err
 		}
 
-		var config KubeUIConfig
-		if err := json.Unmarshal(data, &config); err != nil {
-			return fmt.Errorf("error parsing .kube-ui file: %v", err)
+		// 使用 --config 指定配置时不再询问
+		if *configName != "" {
+			for _, cfg := range config.Configs {
+				if cfg.Name == *configName {
+					useKubeUIConfig(cfg)
+					return nil
+				}
+			}
+			return fmt.Errorf("config %q not found in %s", *configName, kubeUIPath)
 		}
 
 		if len(config.Configs) > 0 {
@@ -448,19 +643,58 @@ func loadKubeUIConfig() error {
 				os.Exit(0)
 			}
 
-			selectedConfig := config.Configs[selectedIndex]
-			*kubeConfig = selectedConfig.Path
-			if selectedConfig.Namespace != "" {
-				*namespace = selectedConfig.Namespace
-			}
+			useKubeUIConfig(config.Configs[selectedIndex])
 		}
+	} else if *configName != "" {
+		return fmt.Errorf("config %q not found, %s does not exist", *configName, kubeUIPath)
 	}
 	return nil
 }
 
+// 使用选中的配置, -n 指定的命名空间优先
+func useKubeUIConfig(selectedConfig KubeConfig) {
+	currentConfig = selectedConfig
+	*kubeConfig = selectedConfig.Path
+	if *namespace == "" && selectedConfig.Namespace != "" {
+		*namespace = selectedConfig.Namespace
+	}
+}
+
+// 按 kubeconfig 路径查找 .kube-ui 中的配置项, 找不到时返回只有路径的配置
+// 读取并解析 ~/.kube-ui
+func readKubeUIConfig() (KubeUIConfig, error) {
+	var config KubeUIConfig
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return config, fmt.Errorf("error getting home directory: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(homeDir, ".kube-ui"))
+	if err != nil {
+		return config, fmt.Errorf("error reading .kube-ui file: %v", err)
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("error parsing .kube-ui file: %v", err)
+	}
+	return config, nil
+}
+
+func findKubeUIConfigByPath(path string) KubeConfig {
+	config, err := readKubeUIConfig()
+	if err != nil {
+		return KubeConfig{Path: path}
+	}
+	for _, cfg := range config.Configs {
+		if cfg.Path == path {
+			return cfg
+		}
+	}
+	return KubeConfig{Path: path}
+}
+
 func handleNamespacePvcAction() {
 	// 获取Pvc列表
-	pvcList, err := k8sClient.CoreV1().PersistentVolumeClaims(*namespace).List(context.TODO(), metav1.ListOptions{})
+	items, err := cachedList[v1.PersistentVolumeClaim]("pvc")
+	pvcList := &v1.PersistentVolumeClaimList{Items: items}
 	if err != nil {
 		fmt.Printf("Error listing pvcs: %v\n", err)
 		fmt.Printf("Failed to get the Pvc list under namespace %s", *namespace)
@@ -469,26 +703,59 @@ func handleNamespacePvcAction() {
 	// 打印Pvc列表
 	fmt.Println("pvc in namespace", *namespace)
 	printPvcTable(pvcList, "", nil)
+	filter := ""
 	for {
 		input := ""
 		prompt := &survey.Input{
-			Message: "Enter pvc number or search, exit to quit: ",
+			Message: "Enter pvc number, name or search; 1,3-5, all or status=X for bulk actions; sort <column>, watch, exit: ",
 		}
 		survey.AskOne(prompt, &input)
 
-		// 	// 检查输入是否为数字
-		pvcNumber, err := strconv.Atoi(input)
-		if err == nil && pvcNumber >= 0 && pvcNumber < len(pvcList.Items) {
-			selectedPvc := pvcList.Items[pvcNumber]
-			handlePvcAction(line, selectedPvc)
-			pvcList, _ = k8sClient.CoreV1().PersistentVolumeClaims(*namespace).List(context.TODO(), metav1.ListOptions{})
+		if input == "watch" {
+			watchResourceTable("pvc")
+			pvcList.Items, _ = cachedList[v1.PersistentVolumeClaim]("pvc")
 			printPvcTable(pvcList, "", nil)
-		} else {
-			//如果== exit 退出
-			shouldReturn := checkExitCode(input)
-			if shouldReturn {
-				return
+			filter = ""
+			continue
+		}
+		if input == "sort" || strings.HasPrefix(input, "sort ") {
+			handleSortCommand[v1.PersistentVolumeClaim]("pvc", input)
+			pvcList.Items, _ = cachedList[v1.PersistentVolumeClaim]("pvc")
+			printPvcTable(pvcList, "", nil)
+			filter = ""
+			continue
+		}
+		//如果== exit 退出
+		if checkExitCode(input) {
+			return
+		}
+		// 批量选择: 3-7, 1,4,9, all 或 status=...
+		if isBulkSelection(input) {
+			selected, err := selectBulkItems(pvcList.Items, input, filter, func(item v1.PersistentVolumeClaim) string { return item.Name }, func(item v1.PersistentVolumeClaim) []string { return []string{string(item.Status.Phase)} })
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+			handleBulkAction("pvc", newBulkTargets("pvc", selected))
+			pvcList.Items, _ = cachedList[v1.PersistentVolumeClaim]("pvc")
+			printPvcTable(pvcList, "", nil)
+			filter = ""
+			continue
+		}
+		// 按编号, 名称或唯一的名称前缀选择, 操作前确认对象没有被删除或替换
+		if selectedPvc, ok := selectListItem(pvcList.Items, input, func(item v1.PersistentVolumeClaim) string { return item.Name }); ok {
+			live, err := resolveSelected[v1.PersistentVolumeClaim](pvcRef(selectedPvc.Name), selectedPvc.UID, selectedPvc.ResourceVersion)
+			if err != nil {
+				fmt.Println(err)
+			} else {
+				handlePvcAction(line, *live)
+				expectLiveInCache(pvcRef(selectedPvc.Name))
 			}
+			pvcList.Items, _ = cachedList[v1.PersistentVolumeClaim]("pvc")
+			printPvcTable(pvcList, "", nil)
+			filter = ""
+		} else {
+			filter = input
 			printPvcTable(pvcList, input, func(pvc v1.PersistentVolumeClaim, input string) bool {
 				return strings.Contains(pvc.Name, input)
 			})
@@ -503,8 +770,10 @@ func handlePvcAction(line *liner.State, selectedPvc v1.PersistentVolumeClaim) {
 		// 高亮显示选中的Pvc名称
 		fmt.Printf("Selected Pvc: \033[1;33m %s \033[0m \n", selectedPvc.Name)
 		fmt.Println("====================================")
-		fmt.Println("command action [p, exit]: ")
+		fmt.Println("command action [p, e, r, exit]: ")
 		fmt.Println("\u001B[0;31m p \u001B[0m: print Pvc info")
+		fmt.Println("\u001B[0;31m e \u001B[0m: edit Pvc")
+		fmt.Println("\u001B[0;31m r \u001B[0m: restore Pvc from local snapshot")
 		fmt.Println("\u001B[0;31m exit \u001B[0m: quit current action")
 
 		action, _ := line.Prompt("Enter action: ")
@@ -518,6 +787,10 @@ func handlePvcAction(line *liner.State, selectedPvc v1.PersistentVolumeClaim) {
 			// describe pvc
 			fmt.Println("==============describe pvc======================")
 			execCommand("describe", "pvc", selectedPvc.Name)
+		case "e":
+			handleEditAction(pvcRef(selectedPvc.Name))
+		case "r":
+			handleRestoreAction(pvcRef(selectedPvc.Name))
 		default:
 			shouldReturn := checkExitCode(action)
 			if shouldReturn {
@@ -530,7 +803,8 @@ func handlePvcAction(line *liner.State, selectedPvc v1.PersistentVolumeClaim) {
 
 func handleNamespaceConfigMapAction() {
 	// 获取ConfigMap列表
-	configMaps, err := k8sClient.CoreV1().ConfigMaps(*namespace).List(context.TODO(), metav1.ListOptions{})
+	items, err := cachedList[v1.ConfigMap]("configmap")
+	configMaps := &v1.ConfigMapList{Items: items}
 	if err != nil {
 		fmt.Printf("Error listing configmaps: %v\n", err)
 		fmt.Printf("Failed to retrieve the ConfigMap list in the namespace %s", *namespace)
@@ -540,28 +814,61 @@ func handleNamespaceConfigMapAction() {
 	fmt.Println("ConfigMaps in namespace", *namespace)
 	printConfigMapTable(configMaps, "", nil)
 
+	filter := ""
 	for {
 		input := ""
 		prompt := &survey.Input{
-			Message: "Enter pod number or search, exit to quit: ",
+			Message: "Enter configmap number, name or search; 1,3-5 or all for bulk actions; sort <column>, watch, exit: ",
 		}
 		survey.AskOne(prompt, &input)
 
-		// 	// 检查输入是否为数字
-		podNumber, err := strconv.Atoi(input)
-		if err == nil && podNumber >= 0 && podNumber < len(configMaps.Items) {
-			selectedConfigMap := configMaps.Items[podNumber]
-			handleConfigMapAction(line, selectedConfigMap)
-			configMaps, _ = k8sClient.CoreV1().ConfigMaps(*namespace).List(context.TODO(), metav1.ListOptions{})
+		if input == "watch" {
+			watchResourceTable("configmap")
+			configMaps.Items, _ = cachedList[v1.ConfigMap]("configmap")
 			printConfigMapTable(configMaps, "", nil)
-		} else {
-			//如果== exit 退出
-			shouldReturn := checkExitCode(input)
-			if shouldReturn {
-				return
+			filter = ""
+			continue
+		}
+		if input == "sort" || strings.HasPrefix(input, "sort ") {
+			handleSortCommand[v1.ConfigMap]("configmap", input)
+			configMaps.Items, _ = cachedList[v1.ConfigMap]("configmap")
+			printConfigMapTable(configMaps, "", nil)
+			filter = ""
+			continue
+		}
+		//如果== exit 退出
+		if checkExitCode(input) {
+			return
+		}
+		// 批量选择: 3-7, 1,4,9, all 或 status=...
+		if isBulkSelection(input) {
+			selected, err := selectBulkItems(configMaps.Items, input, filter, func(item v1.ConfigMap) string { return item.Name }, nil)
+			if err != nil {
+				fmt.Println(err)
+				continue
 			}
-			printConfigMapTable(configMaps, input, func(pod v1.ConfigMap, input string) bool {
-				return strings.Contains(pod.Name, input)
+			handleBulkAction("configmap", newBulkTargets("configmap", selected))
+			configMaps.Items, _ = cachedList[v1.ConfigMap]("configmap")
+			printConfigMapTable(configMaps, "", nil)
+			filter = ""
+			continue
+		}
+		// 按编号, 名称或唯一的名称前缀选择, 操作前确认对象没有被删除或替换
+		if selectedConfigMap, ok := selectListItem(configMaps.Items, input, func(item v1.ConfigMap) string { return item.Name }); ok {
+			live, err := resolveSelected[v1.ConfigMap](configMapRef(selectedConfigMap.Name), selectedConfigMap.UID, selectedConfigMap.ResourceVersion)
+			if err != nil {
+				fmt.Println(err)
+			} else {
+				handleConfigMapAction(line, *live)
+				expectLiveInCache(configMapRef(selectedConfigMap.Name))
+			}
+			configMaps.Items, _ = cachedList[v1.ConfigMap]("configmap")
+			printConfigMapTable(configMaps, "", nil)
+			filter = ""
+		} else {
+			filter = input
+			printConfigMapTable(configMaps, input, func(cm v1.ConfigMap, input string) bool {
+				return strings.Contains(cm.Name, input)
 			})
 		}
 	}
@@ -573,10 +880,12 @@ func handleConfigMapAction(line *liner.State, selectedConfigMap v1.ConfigMap) {
 		// 高亮显示选中的ConfigMap名称
 		fmt.Printf("Selected ConfigMap: \033[1;33m %s \033[0m \n", selectedConfigMap.Name)
 		fmt.Println("====================================")
-		fmt.Println("command action [p, e, a, exit]: ")
+		fmt.Println("command action [p, k, e, a, r, exit]: ")
 		fmt.Println("\u001B[0;31m p \u001B[0m: print ConfigMap info")
+		fmt.Println("\u001B[0;31m k \u001B[0m: view and edit ConfigMap keys")
 		fmt.Println("\u001B[0;31m e \u001B[0m: edit ConfigMap")
 		fmt.Println("\u001B[0;31m a \u001B[0m: apply local yaml file to ConfigMap")
+		fmt.Println("\u001B[0;31m r \u001B[0m: restore ConfigMap from local snapshot")
 		fmt.Println("\u001B[0;31m exit \u001B[0m: quit current action")
 
 		action, _ := line.Prompt("Enter action: ")
@@ -585,11 +894,16 @@ func handleConfigMapAction(line *liner.State, selectedConfigMap v1.ConfigMap) {
 		switch action {
 		case "p":
 			execCommand("get", "configmap", selectedConfigMap.Name, "-o", "yaml")
+		case "k":
+			handleConfigMapKeysAction(line, selectedConfigMap.Name)
 		case "e":
-			execCommand("edit", "configmap", selectedConfigMap.Name)
+			handleEditAction(configMapRef(selectedConfigMap.Name))
 		case "a":
 			yamlFile, _ := line.Prompt("Enter local yaml file path: ")
-			execCommand("apply", "-f", yamlFile)
+			ref := configMapRef(selectedConfigMap.Name)
+			handleApplyFileAction(strings.TrimSpace(yamlFile), &ref)
+		case "r":
+			handleRestoreAction(configMapRef(selectedConfigMap.Name))
 		default:
 			shouldReturn := checkExitCode(action)
 			if shouldReturn {
@@ -602,7 +916,8 @@ func handleConfigMapAction(line *liner.State, selectedConfigMap v1.ConfigMap) {
 
 func handleNamespaceSvcAction() {
 	// 获取Service列表
-	svcList, err := k8sClient.CoreV1().Services(*namespace).List(context.TODO(), metav1.ListOptions{})
+	items, err := cachedList[v1.Service]("svc")
+	svcList := &v1.ServiceList{Items: items}
 	if err != nil {
 		fmt.Printf("Error listing services: %v\n", err)
 		fmt.Printf("Failed to get the Service list under namespace %s", *namespace)
@@ -611,27 +926,61 @@ func handleNamespaceSvcAction() {
 	// 打印Service列表
 	fmt.Println("Services in namespace", *namespace)
 	printSvcTable(svcList, "", nil)
+	filter := ""
 	for {
 		input := ""
 		prompt := &survey.Input{
-			Message: "Enter pod number or search, exit to quit: ",
+			Message: "Enter service number, name or search; 1,3-5 or all for bulk actions; sort <column>, watch, exit: ",
 		}
 		survey.AskOne(prompt, &input)
 
-		// 	// 检查输入是否为数字
-		podNumber, err := strconv.Atoi(input)
-		if err == nil && podNumber >= 0 && podNumber < len(svcList.Items) {
-			selectedSvc := svcList.Items[podNumber]
-			handleSvcAction(line, selectedSvc)
-			svcList, _ = k8sClient.CoreV1().Services(*namespace).List(context.TODO(), metav1.ListOptions{})
+		if input == "watch" {
+			watchResourceTable("svc")
+			svcList.Items, _ = cachedList[v1.Service]("svc")
 			printSvcTable(svcList, "", nil)
-		} else {
-			shouldReturn := checkExitCode(input)
-			if shouldReturn {
-				return
+			filter = ""
+			continue
+		}
+		if input == "sort" || strings.HasPrefix(input, "sort ") {
+			handleSortCommand[v1.Service]("svc", input)
+			svcList.Items, _ = cachedList[v1.Service]("svc")
+			printSvcTable(svcList, "", nil)
+			filter = ""
+			continue
+		}
+		//如果== exit 退出
+		if checkExitCode(input) {
+			return
+		}
+		// 批量选择: 3-7, 1,4,9, all 或 status=...
+		if isBulkSelection(input) {
+			selected, err := selectBulkItems(svcList.Items, input, filter, func(item v1.Service) string { return item.Name }, nil)
+			if err != nil {
+				fmt.Println(err)
+				continue
 			}
-			printSvcTable(svcList, input, func(pod v1.Service, input string) bool {
-				return strings.Contains(pod.Name, input)
+			handleBulkAction("svc", newBulkTargets("svc", selected))
+			svcList.Items, _ = cachedList[v1.Service]("svc")
+			printSvcTable(svcList, "", nil)
+			filter = ""
+			continue
+		}
+		// 按编号, 名称或唯一的名称前缀选择, 操作前确认对象没有被删除或替换
+		if selectedSvc, ok := selectListItem(svcList.Items, input, func(item v1.Service) string { return item.Name }); ok {
+			live, err := resolveSelected[v1.Service](serviceRef(selectedSvc.Name), selectedSvc.UID, selectedSvc.ResourceVersion)
+			if err != nil {
+				fmt.Println(err)
+			} else {
+				handleSvcAction(line, *live)
+				expectLiveInCache(serviceRef(selectedSvc.Name))
+			}
+			svcList.Items, _ = cachedList[v1.Service]("svc")
+			printSvcTable(svcList, "", nil)
+			filter = ""
+		} else {
+			filter = input
+			printSvcTable(svcList, input, func(svc v1.Service, input string) bool {
+				return strings.Contains(svc.Name, input)
 			})
 		}
 	}
@@ -641,7 +990,8 @@ func handleNamespaceSvcAction() {
 func handleNamespacePodAction() {
 
 	// 获取Pod列表
-	pods, err := k8sClient.CoreV1().Pods(*namespace).List(context.TODO(), metav1.ListOptions{})
+	items, err := cachedList[v1.Pod]("pods")
+	pods := &v1.PodList{Items: items}
 	if err != nil {
 		fmt.Printf("Error listing pods: %v\n", err)
 		fmt.Printf("Failed to get the Pod list under namespace %s", *namespace)
@@ -654,27 +1004,71 @@ func handleNamespacePodAction() {
 	// 	fmt.Printf("[\u001B[1;31m %d \u001B[0m] %s \u001B[0;32m%s\u001B[0m \n", i, pod.Name, pod.Status.Phase)
 	// }
 	printPodTable(pods, "", nil)
+	filter := ""
 	for {
 
 		input := ""
 		prompt := &survey.Input{
-			Message: "Enter pod number or search, exit to quit: ",
+			Message: "Enter pod number, name or search; 1,3-5, all or status=X for bulk actions; sort <column>, cleanup, tail, watch, exit: ",
 		}
 		survey.AskOne(prompt, &input)
 
-		// 	// 检查输入是否为数字
-		podNumber, err := strconv.Atoi(input)
-		if err == nil && podNumber >= 0 && podNumber < len(pods.Items) {
-			selectedPod := pods.Items[podNumber]
-			handlePodAction(line, selectedPod)
-			pods, _ = k8sClient.CoreV1().Pods(*namespace).List(context.TODO(), metav1.ListOptions{})
+		if input == "tail" {
+			handleLogTailAction()
 			printPodTable(pods, "", nil)
-		} else {
-			//如果== exit 退出
-			shouldReturn := checkExitCode(input)
-			if shouldReturn {
-				return
+			continue
+		}
+		if input == "cleanup" {
+			handlePodCleanupAction()
+			pods.Items, _ = cachedList[v1.Pod]("pods")
+			printPodTable(pods, "", nil)
+			filter = ""
+			continue
+		}
+		if input == "watch" {
+			watchResourceTable("pods")
+			pods.Items, _ = cachedList[v1.Pod]("pods")
+			printPodTable(pods, "", nil)
+			filter = ""
+			continue
+		}
+		if input == "sort" || strings.HasPrefix(input, "sort ") {
+			handleSortCommand[v1.Pod]("pods", input)
+			pods.Items, _ = cachedList[v1.Pod]("pods")
+			printPodTable(pods, "", nil)
+			filter = ""
+			continue
+		}
+		//如果== exit 退出
+		if checkExitCode(input) {
+			return
+		}
+		// 批量选择: 3-7, 1,4,9, all 或 status=...
+		if isBulkSelection(input) {
+			selected, err := selectBulkItems(pods.Items, input, filter, func(item v1.Pod) string { return item.Name }, podStatuses)
+			if err != nil {
+				fmt.Println(err)
+				continue
 			}
+			handleBulkAction("pods", newBulkTargets("pods", selected))
+			pods.Items, _ = cachedList[v1.Pod]("pods")
+			printPodTable(pods, "", nil)
+			filter = ""
+			continue
+		}
+		// 按编号, 名称或唯一的名称前缀选择, 操作前确认对象没有被删除或替换
+		if selectedPod, ok := selectListItem(pods.Items, input, func(item v1.Pod) string { return item.Name }); ok {
+			live, err := resolveSelected[v1.Pod](podRef(selectedPod.Name), selectedPod.UID, selectedPod.ResourceVersion)
+			if err != nil {
+				fmt.Println(err)
+			} else {
+				handlePodAction(line, *live)
+				expectLiveInCache(podRef(selectedPod.Name))
+			}
+			pods.Items, _ = cachedList[v1.Pod]("pods")
+			printPodTable(pods, "", nil)
+			filter = ""
+		} else {
 			// 搜索Pod名称
 			//fmt.Println("Searching for pods containing:", input)
 			// for i, pod := range pods.Items {
@@ -682,6 +1076,7 @@ func handleNamespacePodAction() {
 			// 		fmt.Printf("[\u001B[1;31m %d \u001B[0m] %s \u001B[0;32m%s\u001B[0m \n", i, pod.Name, pod.Status.Phase)
 			// 	}
 			// }
+			filter = input
 			printPodTable(pods, input, func(pod v1.Pod, input string) bool {
 				return strings.Contains(pod.Name, input)
 			})
@@ -703,8 +1098,14 @@ func checkExitCode(input string) bool {
 }
 
 func printPvcTable(pvcList *v1.PersistentVolumeClaimList, s string, f func(pvc v1.PersistentVolumeClaim, input string) bool) {
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"Number", "Name", "Status", "StorageClass", "Capacity", "AccessMode"})
+	buildPvcTable(pvcList, s, f).render()
+}
+
+func buildPvcTable(pvcList *v1.PersistentVolumeClaimList, s string, f func(pvc v1.PersistentVolumeClaim, input string) bool) *outputTable {
+	table := newOutputTable("Number", "Name", "Status", "StorageClass", "Capacity", "AccessMode").
+		wide("Volume").
+		forKind("v1", "PersistentVolumeClaim")
+	table.total = len(pvcList.Items)
 	for i, pvc := range pvcList.Items {
 		if f != nil && !f(pvc, s) {
 			continue
@@ -713,54 +1114,84 @@ func printPvcTable(pvcList *v1.PersistentVolumeClaimList, s string, f func(pvc v
 		for _, model := range pvc.Spec.AccessModes {
 			models = append(models, string(model))
 		}
-		table.Append([]string{
+		table.append(&pvcList.Items[i],
 			fmt.Sprintf("%d", i),
 			pvc.Name,
 			string(pvc.Status.Phase),
-			*pvc.Spec.StorageClassName,
+			ptr.Deref(pvc.Spec.StorageClassName, ""),
 			pvc.Status.Capacity.Storage().String(),
 			strings.Join(models, ","),
-		})
+			pvc.Spec.VolumeName,
+		)
 	}
-	table.Render()
-
+	return table
 }
 
 func printConfigMapTable(configMapList *v1.ConfigMapList, input string, f func(pod v1.ConfigMap, input string) bool) {
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"Number", "Name", "Data"})
+	buildConfigMapTable(configMapList, input, f).render()
+}
+
+func buildConfigMapTable(configMapList *v1.ConfigMapList, input string, f func(pod v1.ConfigMap, input string) bool) *outputTable {
+	table := newOutputTable("Number", "Name", "Data", "BinaryData", "Size").
+		wide("Keys").
+		forKind("v1", "ConfigMap")
+	table.total = len(configMapList.Items)
 	for i, pod := range configMapList.Items {
 		if f != nil && !f(pod, input) {
 			continue
 		}
-		table.Append([]string{fmt.Sprintf("%d", i), pod.Name, fmt.Sprintf("%d", len(pod.Data))})
+		var keys []string
+		for _, k := range configMapKeys(&configMapList.Items[i]) {
+			keys = append(keys, k.Name)
+		}
+		table.append(&configMapList.Items[i], fmt.Sprintf("%d", i), pod.Name, fmt.Sprintf("%d", len(pod.Data)), fmt.Sprintf("%d", len(pod.BinaryData)), formatBytes(configMapSize(pod)),
+			strings.Join(keys, ","))
 	}
-	table.Render()
+	return table
 }
 
 func printPodTable(pods *v1.PodList, input string, f func(pod v1.Pod, input string) bool) {
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"Number", "pod-Name", "pod-Status", "restart-times", "age"})
+	buildPodTable(pods, input, f).render()
+}
+
+func buildPodTable(pods *v1.PodList, input string, f func(pod v1.Pod, input string) bool) *outputTable {
+	table := newOutputTable("Number", "pod-Name", "pod-Status", "restart-times", "age").
+		wide("IP", "Node").
+		forKind("v1", "Pod")
+	table.total = len(pods.Items)
 	for i, pod := range pods.Items {
 		if f != nil && !f(pod, input) {
 			continue
 		}
-		age := metav1.Now().Sub(pod.Status.StartTime.Time).Round(time.Minute)
-		restartCount := 0
-		table.Append([]string{
+		// 调度前的 pod 没有 startTime
+		startTime := pod.CreationTimestamp
+		if pod.Status.StartTime != nil {
+			startTime = *pod.Status.StartTime
+		}
+		age := metav1.Now().Sub(startTime.Time).Round(time.Minute)
+		restartCount := podRestarts(&pods.Items[i])
+		table.append(&pods.Items[i],
 			fmt.Sprintf("%d", i),
 			pod.Name,
-			string(pod.Status.Phase),
+			podDisplayStatus(&pods.Items[i]),
 			fmt.Sprintf("%d", restartCount),
 			age.String(),
-		})
+			pod.Status.PodIP,
+			pod.Spec.NodeName,
+		)
 	}
-	table.Render()
+	return table
 }
 
 func printSvcTable(pods *v1.ServiceList, input string, f func(pod v1.Service, input string) bool) {
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"Number", "Name", "TYPE", "CLUSTER-IP", "EXTERNAL-IP", "PORT(S)"})
+	buildSvcTable(pods, input, f).render()
+}
+
+func buildSvcTable(pods *v1.ServiceList, input string, f func(pod v1.Service, input string) bool) *outputTable {
+	table := newOutputTable("Number", "Name", "TYPE", "CLUSTER-IP", "EXTERNAL-IP", "PORT(S)").
+		wide("SELECTOR").
+		forKind("v1", "Service")
+	table.total = len(pods.Items)
 	for i, pod := range pods.Items {
 		if f != nil && !f(pod, input) {
 			continue
@@ -769,16 +1200,22 @@ func printSvcTable(pods *v1.ServiceList, input string, f func(pod v1.Service, in
 		for _, port := range pod.Spec.Ports {
 			ports = append(ports, fmt.Sprintf("%d/%s", port.Port, port.Protocol))
 		}
-		table.Append([]string{
+		var selector []string
+		for k, v := range pod.Spec.Selector {
+			selector = append(selector, k+"="+v)
+		}
+		sort.Strings(selector)
+		table.append(&pods.Items[i],
 			fmt.Sprintf("%d", i),
 			pod.Name,
 			string(pod.Spec.Type),
 			pod.Spec.ClusterIP,
 			strings.Join(pod.Spec.ExternalIPs, ","),
 			strings.Join(ports, ","),
-		})
+			strings.Join(selector, ","),
+		)
 	}
-	table.Render()
+	return table
 }
 
 func handleSvcAction(line *liner.State, svc v1.Service) {
@@ -787,9 +1224,11 @@ func handleSvcAction(line *liner.State, svc v1.Service) {
 		// 高亮显示选中的svc名称
 		fmt.Printf("Selected svc: \033[1;33m %s \033[0m \n", svc.Name)
 		fmt.Println("====================================")
-		fmt.Println("command action [p fw exit]: ")
+		fmt.Println("command action [p fw e r exit]: ")
 		fmt.Println("\u001B[0;31m p \u001B[0m: print svc info")
 		fmt.Println("\u001B[0;31m fw \u001B[0m: forward svc port")
+		fmt.Println("\u001B[0;31m e \u001B[0m: edit svc")
+		fmt.Println("\u001B[0;31m r \u001B[0m: restore svc from local snapshot")
 		fmt.Println("\u001B[0;31m exit \u001B[0m: quit current action")
 
 		action, _ := line.Prompt("Enter action: ")
@@ -805,16 +1244,11 @@ func handleSvcAction(line *liner.State, svc v1.Service) {
 			execCommand("describe", "svc", svc.Name)
 		case "fw":
 			ports, _ := line.Prompt("please enter forward ports, example: \"localPort1:svcPort1 localPort2:svcPort2\", so you can input \"8080:80 9090:90\" ")
-			ports = strings.TrimSpace(ports)
-			forwardPort := []string{"port-forward", "svc/" + svc.Name}
-			for _, portValue := range strings.Split(ports, " ") {
-				portPairNew := strings.TrimSpace(portValue)
-				if portPairNew == "" {
-					continue
-				}
-				forwardPort = append(forwardPort, portPairNew)
-			}
-			execCommand(forwardPort...)
+			forwardPorts("svc/"+svc.Name, strings.Fields(ports))
+		case "e":
+			handleEditAction(serviceRef(svc.Name))
+		case "r":
+			handleRestoreAction(serviceRef(svc.Name))
 		default:
 			shouldReturn := checkExitCode(action)
 			if shouldReturn {
@@ -831,16 +1265,18 @@ func handlePodAction(line *liner.State, pod v1.Pod) {
 		// 高亮显示选中的Pod名称
 		fmt.Printf("Selected pod: \033[1;33m %s \033[0m \n", pod.Name)
 		fmt.Println("====================================")
-		fmt.Println("command action [p, l, lf, s, e, fw, cp, u, del, exit]: ")
+		fmt.Println("command action [p, l, lf, s, e, ed, fw, cp, u, del, r, exit]: ")
 		fmt.Println("\u001B[0;31m p \u001B[0m: print pod info")
 		fmt.Println("\u001B[0;31m l \u001B[0m: view all logs")
 		fmt.Println("\u001B[0;31m lf \u001B[0m: view rolling logs")
 		fmt.Println("\u001B[0;31m s \u001B[0m: enter shell")
 		fmt.Println("\u001B[0;31m e \u001B[0m: view pod events")
+		fmt.Println("\u001B[0;31m ed \u001B[0m: edit pod")
 		fmt.Println("\u001B[0;31m fw \u001B[0m: port forward remote port to local")
 		fmt.Println("\u001B[0;31m cp \u001B[0m: copy remote file to current path, download file name is remote file name")
 		fmt.Println("\u001B[0;31m u \u001B[0m: upload local file to remote pod")
 		fmt.Println("\u001B[0;31m del \u001B[0m: delete pod")
+		fmt.Println("\u001B[0;31m r \u001B[0m: restore pod from local snapshot")
 		fmt.Println("\u001B[0;31m exit \u001B[0m: quit current action")
 
 		action, _ := line.Prompt("Enter action: ")
@@ -861,10 +1297,10 @@ func handlePodAction(line *liner.State, pod v1.Pod) {
 			// cmd.Run()
 		case "l":
 			// 查看日志
-			execCommand("logs", pod.Name)
+			podLogs(pod.Name, "", false)
 		case "lf":
 			// 查看滚动日志
-			execCommand("logs", "-f", "--tail=1000", pod.Name)
+			podLogs(pod.Name, "", true)
 		case "cp":
 			// 复制文件
 			src, _ := line.Prompt("Enter remote file path: ")
@@ -880,22 +1316,14 @@ func handlePodAction(line *liner.State, pod v1.Pod) {
 			// 处理容器选择
 			if len(pod.Spec.Containers) == 1 {
 				// 只有一个容器时直接进入
-				if err := execCommand("exec", "-it", pod.Name, "--", "/bin/bash"); err != nil {
-					// If bash fails, try sh
-					execCommand("exec", "-it", pod.Name, "--", "/bin/sh")
-				}
+				podShell(pod.Name, "")
 			} else {
 				// 多个容器时显示选择表格
-				table := tablewriter.NewWriter(os.Stdout)
-				table.SetHeader([]string{"Number", "Container Name"})
-
+				table := newOutputTable("Number", "Container Name")
 				for i, container := range pod.Spec.Containers {
-					table.Append([]string{
-						fmt.Sprintf("%d", i),
-						container.Name,
-					})
+					table.append(nil, fmt.Sprintf("%d", i), container.Name)
 				}
-				table.Render()
+				table.render()
 
 				// 让用户选择容器
 				var containerNum string
@@ -905,10 +1333,7 @@ func handlePodAction(line *liner.State, pod v1.Pod) {
 				survey.AskOne(prompt, &containerNum)
 
 				if num, err := strconv.Atoi(containerNum); err == nil && num >= 0 && num < len(pod.Spec.Containers) {
-					if err := execCommand("exec", "-it", pod.Name, "-c", pod.Spec.Containers[num].Name, "--", "/bin/bash"); err != nil {
-						// If bash fails, try sh
-						execCommand("exec", "-it", pod.Name, "-c", pod.Spec.Containers[num].Name, "--", "/bin/sh")
-					}
+					podShell(pod.Name, pod.Spec.Containers[num].Name)
 				} else {
 					fmt.Println("Invalid container number")
 				}
@@ -916,22 +1341,16 @@ func handlePodAction(line *liner.State, pod v1.Pod) {
 		case "e":
 			// 查看pod事件
 			printPodEvents(pod)
+		case "ed":
+			handleEditAction(podRef(pod.Name))
 		case "fw":
 			// 端口转发
 			ports, _ := line.Prompt("please enter forward ports, example: \"localPort1:podPort1 localPort2:podPort2\", so you can input \"8080:80 9090:90\" ")
-			ports = strings.TrimSpace(ports)
-			forwardPort := []string{"port-forward", "pod/" + pod.Name}
-			for _, portPair := range strings.Split(ports, " ") {
-				portPairNew := strings.TrimSpace(portPair)
-				if portPairNew == "" {
-					continue
-				}
-				forwardPort = append(forwardPort, portPairNew)
-			}
-			execCommand(forwardPort...)
+			forwardPorts("pod/"+pod.Name, strings.Fields(ports))
 		case "del":
-			// 删除pod
-			execCommand("delete", "pod", pod.Name)
+			deletePod(pod.Name, pod.UID)
+		case "r":
+			handleRestoreAction(podRef(pod.Name))
 		default:
 			shouldReturn := checkExitCode(action)
 			if shouldReturn {
@@ -942,40 +1361,110 @@ func handlePodAction(line *liner.State, pod v1.Pod) {
 	}
 }
 
+// 查看 pod 日志, follow 时从最后 1000 行开始滚动输出
+func podLogs(name, container string, follow bool) error {
+	args := []string{"logs"}
+	if follow {
+		args = append(args, "-f", "--tail=1000")
+	}
+	if container != "" {
+		args = append(args, "-c", container)
+	}
+	return execCommand(append(args, name)...)
+}
+
+// 进入容器的 shell, 没有 bash 时使用 sh
+func podShell(name, container string) error {
+	args := []string{"exec", "-it", name}
+	if container != "" {
+		args = append(args, "-c", container)
+	}
+	if err := execCommand(append(args, "--", "/bin/bash")...); err != nil {
+		// If bash fails, try sh
+		return execCommand(append(args, "--", "/bin/sh")...)
+	}
+	return nil
+}
+
+// 删除 pod, 删除前先备份
+// uid 不为空时作为删除的前置条件, 同名的新 pod 不会被误删
+func deletePod(name string, uid types.UID) error {
+	if !backupBeforeChange(podRef(name), "delete") {
+		return fmt.Errorf("delete cancelled")
+	}
+	options := metav1.DeleteOptions{}
+	if uid != "" {
+		options.Preconditions = metav1.NewUIDPreconditions(string(uid))
+	}
+	ctx, cancel := requestContext()
+	defer cancel()
+	err := requestError(ctx, k8sClient.CoreV1().Pods(*namespace).Delete(ctx, name, options))
+	auditAPI("delete", "delete", "pod/"+name, err)
+	switch {
+	case apierrors.IsConflict(err):
+		err = fmt.Errorf("pod %s was replaced by a new pod with the same name, refusing to delete it", name)
+	case err == nil:
+		fmt.Printf("pod \"%s\" deleted\n", name)
+	}
+	if err != nil {
+		fmt.Printf("Error deleting pod %s: %v\n", name, err)
+	}
+	return err
+}
+
+// 把 pod/xxx 或 svc/xxx 的端口转发到本地, ports 形如 8080:80
+func forwardPorts(target string, ports []string) error {
+	if len(ports) == 0 {
+		return fmt.Errorf("no ports to forward")
+	}
+	return execCommand(append([]string{"port-forward", target}, ports...)...)
+}
+
 // 添加新函数用于打印pod事件
 func printPodEvents(pod v1.Pod) {
 	// 获取pod相关的事件
-	events, err := k8sClient.CoreV1().Events(*namespace).List(context.TODO(), metav1.ListOptions{
+	ctx, cancel := requestContext()
+	defer cancel()
+	events, err := k8sClient.CoreV1().Events(*namespace).List(ctx, metav1.ListOptions{
 		FieldSelector: fmt.Sprintf("involvedObject.name=%s,involvedObject.kind=Pod", pod.Name),
 	})
 	if err != nil {
+		err = requestError(ctx, err)
 		fmt.Printf("Error getting pod events: %v\n", err)
 		return
 	}
 
 	// 创建表格
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"Type", "Reason", "Age", "From", "Message"})
+	table := newOutputTable("Type", "Reason", "Age", "From", "Message").
+		wide("Count", "FirstSeen", "LastSeen").
+		forKind("v1", "Event")
 
 	// 添加事件数据
-	for _, event := range events.Items {
+	for i, event := range events.Items {
 		age := metav1.Now().Sub(event.FirstTimestamp.Time).Round(time.Minute)
-		table.Append([]string{
+		table.append(&events.Items[i],
 			event.Type,
 			event.Reason,
 			age.String(),
 			event.Source.Component,
 			event.Message,
-		})
+			fmt.Sprintf("%d", event.Count),
+			event.FirstTimestamp.Format(time.RFC3339),
+			event.LastTimestamp.Format(time.RFC3339),
+		)
 	}
 
 	// 渲染表格
-	table.Render()
+	table.render()
 }
 
-func execCommand(arg ...string) error {
+func execCommand(arg ...string) (err error) {
 	defaultArg := []string{"--kubeconfig", *kubeConfig, "-n", *namespace}
 	arg = append(defaultArg, arg...)
+	// 记录审计日志
+	defer func() {
+		auditCommand("kubectl", arg, err)
+	}()
 	cmd := exec.Command("kubectl", arg...)
 	fmt.Println("exec command: \u001B[0;31m " + cmd.String() + " \u001B[0m")
 	cmd.Stdin = os.Stdin
@@ -1008,180 +1497,3 @@ func execCommand(arg ...string) error {
 		return err
 	}
 }
-
-func handleTunnelAction() {
-	for {
-		var input string
-		prompt := &survey.Input{
-			Message: "Enter target address (e.g. 10.0.0.1:8080 or my-svc.ns:8080), or 'exit' to quit: ",
-		}
-		survey.AskOne(prompt, &input)
-
-		input = strings.TrimSpace(input)
-		shouldReturn := checkExitCode(input)
-		if shouldReturn {
-			return
-		}
-
-		// Parse host and port
-		parts := strings.Split(input, ":")
-		if len(parts) != 2 {
-			fmt.Println("Invalid format. Please use host:port")
-			continue
-		}
-
-		host, port := parts[0], parts[1]
-
-		// Get local port
-		var localPort string
-		promptLocal := &survey.Input{
-			Message: "Enter local port to forward to: ",
-		}
-		survey.AskOne(promptLocal, &localPort)
-
-		localPort = strings.TrimSpace(localPort)
-		if localPort == "" {
-			fmt.Println("Local port is required")
-			continue
-		}
-
-		// 获取镜像和密钥配置
-		tunnelImage := "alpine/socat" // 默认镜像
-		var imagePullSecrets []v1.LocalObjectReference
-
-		// 从当前配置中获取镜像和密钥信息
-		homeDir, _ := os.UserHomeDir()
-		kubeUIPath := filepath.Join(homeDir, ".kube-ui")
-		if _, err := os.Stat(kubeUIPath); err == nil {
-			data, err := os.ReadFile(kubeUIPath)
-			if err == nil {
-				var config KubeUIConfig
-				if err := json.Unmarshal(data, &config); err == nil {
-					// 查找当前使用的配置
-					for _, cfg := range config.Configs {
-						if cfg.Path == *kubeConfig {
-							if cfg.TunnelImage != "" {
-								tunnelImage = cfg.TunnelImage
-							}
-							if cfg.ImagePullSecret != "" {
-								imagePullSecrets = append(imagePullSecrets, v1.LocalObjectReference{
-									Name: cfg.ImagePullSecret,
-								})
-							}
-							break
-						}
-					}
-				}
-			}
-		}
-
-		tunnelPod := &v1.Pod{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      fmt.Sprintf("tunnel-pod-%s", randomString(6)),
-				Namespace: *namespace,
-			},
-			Spec: v1.PodSpec{
-				ImagePullSecrets: imagePullSecrets,
-				Containers: []v1.Container{
-					{
-						Name:            "tunnel",
-						Image:           tunnelImage,
-						ImagePullPolicy: "IfNotPresent",
-						Command: []string{
-							"socat",
-							fmt.Sprintf("TCP-LISTEN:%s,fork,reuseaddr", port),
-							fmt.Sprintf("TCP:%s:%s", host, port),
-						},
-					},
-				},
-				RestartPolicy:         v1.RestartPolicyNever,
-				ActiveDeadlineSeconds: ptr.To(int64(3600)),
-			},
-		}
-
-		fmt.Printf("Creating tunnel pod for %s:%s...\n", host, port)
-		pod, err := k8sClient.CoreV1().Pods(*namespace).Create(context.TODO(), tunnelPod, metav1.CreateOptions{})
-		if err != nil {
-			fmt.Printf("Error creating tunnel pod: %v\n", err)
-			continue
-		}
-
-		// Wait for pod to be ready
-		fmt.Println("Waiting for tunnel pod to be ready...")
-		startTime := time.Now()
-		// 超过100次，则退出
-		for i := 0; i < 100; i++ {
-			pod, err = k8sClient.CoreV1().Pods(*namespace).Get(context.TODO(), pod.Name, metav1.GetOptions{})
-			if err != nil {
-				fmt.Printf("Error getting pod status: %v\n", err)
-				break
-			}
-			if pod.Status.Phase == v1.PodRunning {
-				break
-			}
-			time.Sleep(1 * time.Second)
-			fmt.Printf("Waiting for tunnel pod. Total time cost: %s\n", time.Since(startTime))
-			fmt.Printf("Pod status: %s\n", pod.Status.Phase)
-
-			// 输出容器状态和错误信息
-			for _, containerStatus := range pod.Status.ContainerStatuses {
-				if containerStatus.State.Waiting != nil {
-					fmt.Printf("Container %s is waiting: %s - %s\n",
-						containerStatus.Name,
-						containerStatus.State.Waiting.Reason,
-						containerStatus.State.Waiting.Message)
-				}
-				if containerStatus.State.Terminated != nil {
-					fmt.Printf("Container %s terminated: %s - %s (exit code: %d)\n",
-						containerStatus.Name,
-						containerStatus.State.Terminated.Reason,
-						containerStatus.State.Terminated.Message,
-						containerStatus.State.Terminated.ExitCode)
-				}
-			}
-
-			// 输出 pod 事件
-			events, err := k8sClient.CoreV1().Events(*namespace).List(context.TODO(), metav1.ListOptions{
-				FieldSelector: fmt.Sprintf("involvedObject.name=%s,involvedObject.kind=Pod", pod.Name),
-			})
-			if err == nil {
-				for _, event := range events.Items {
-					fmt.Printf("Event: Type=%s Reason=%s Message=%s\n",
-						event.Type,
-						event.Reason,
-						event.Message)
-				}
-			}
-		}
-		// 超过10次，则退出
-		if err != nil {
-			fmt.Printf("Error getting pod status: %v\n", err)
-			break
-		}
-		// 如果pod不是running状态，则退出
-		if pod.Status.Phase != v1.PodRunning {
-			fmt.Printf("Tunnel pod %s is not running\n", pod.Name)
-			break
-		}
-
-		fmt.Printf("Tunneling %s:%s to localhost:%s\n", host, port, localPort)
-		execCommand("port-forward", fmt.Sprintf("pod/%s", pod.Name), fmt.Sprintf("%s:%s", localPort, port))
-
-		// Cleanup
-		fmt.Println("Cleaning up tunnel pod...")
-		err = k8sClient.CoreV1().Pods(*namespace).Delete(context.TODO(), pod.Name, metav1.DeleteOptions{})
-		if err != nil {
-			fmt.Printf("Error deleting tunnel pod: %v\n", err)
-		}
-	}
-}
-
-// 生成随机字符串
-func randomString(n int) string {
-	const letterBytes = "abcdefghijklmnopqrstuvwxyz0123456789"
-	b := make([]byte, n)
-	for i := range b {
-		b[i] = letterBytes[rand.Intn(len(letterBytes))]
-	}
-	return string(b)
-}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/olekukonko/tablewriter"
+	"k8s.io/client-go/util/jsonpath"
+	"sigs.k8s.io/yaml"
+)
+
+// 输出格式, 通过 -o 指定, 交互模式中可以在主菜单切换
+var outputFormat *string
+
+var outputFormats = []string{"table", "wide", "json", "yaml", "csv", "custom-columns"}
+
+// table/wide 格式最多显示的行数, 0 表示不限制, 只在交互模式中设置
+var tableRowLimit int
+
+// 交互模式中使用 json/yaml/custom-columns 输出时, 带 Number 列的表格仍然显示编号, 用于选择对象
+var numberedOutput bool
+
+func listLimit() int {
+	if currentConfig.ListLimit > 0 {
+		return currentConfig.ListLimit
+	}
+	return 0
+}
+
+func init() {
+	outputFormat = rootCmd.PersistentFlags().StringP("output", "o", "table", "output format: table, wide, json, yaml, csv or custom-columns=NAME:.metadata.name,...")
+}
+
+func validateOutputFormat(format string) error {
+	if spec, ok := strings.CutPrefix(format, "custom-columns="); ok {
+		_, err := parseCustomColumns(spec)
+		return err
+	}
+	for _, f := range outputFormats[:len(outputFormats)-1] {
+		if format == f {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown output format %q, expected one of %s", format, strings.Join(outputFormats, ", "))
+}
+
+// 表格的行以及每一行对应的对象, 同一份数据可以按不同格式输出
+type outputTable struct {
+	apiVersion  string
+	kind        string
+	headers     []string
+	wideHeaders []string
+	rows        [][]string
+	objects     []interface{}
+	// 过滤前的对象数量, 用于显示 showing N of M
+	total int
+}
+
+func newOutputTable(headers ...string) *outputTable {
+	return &outputTable{headers: headers}
+}
+
+// wide 格式中额外显示的列
+func (t *outputTable) wide(headers ...string) *outputTable {
+	t.wideHeaders = headers
+	return t
+}
+
+// json/yaml 输出时补全对象的 apiVersion 和 kind, 列表中的对象没有这两个字段
+func (t *outputTable) forKind(apiVersion, kind string) *outputTable {
+	t.apiVersion = apiVersion
+	t.kind = kind
+	return t
+}
+
+// 添加一行, cells 依次对应 headers 和 wide headers, obj 为 nil 时 json/yaml 输出该行的列
+func (t *outputTable) append(obj interface{}, cells ...string) {
+	t.rows = append(t.rows, cells)
+	t.objects = append(t.objects, obj)
+}
+
+func (t *outputTable) render() {
+	if err := t.write(os.Stdout, *outputFormat); err != nil {
+		fmt.Printf("Error rendering output: %v\n", err)
+	}
+}
+
+func (t *outputTable) write(w io.Writer, format string) error {
+	switch {
+	case format == "" || format == "table":
+		t.writeTable(w, len(t.headers))
+	case format == "wide":
+		t.writeTable(w, len(t.headers)+len(t.wideHeaders))
+	case format == "json":
+		data, err := json.MarshalIndent(t.document(), "", "    ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, string(data))
+		t.writeNumbers(w)
+	case format == "yaml":
+		data, err := yaml.Marshal(t.document())
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(w, string(data))
+		t.writeNumbers(w)
+	case format == "csv":
+		cw := csv.NewWriter(w)
+		cw.Write(append(append([]string{}, t.headers...), t.wideHeaders...))
+		for _, row := range t.rows {
+			cw.Write(row)
+		}
+		cw.Flush()
+		return cw.Error()
+	case strings.HasPrefix(format, "custom-columns="):
+		return t.writeCustomColumns(w, strings.TrimPrefix(format, "custom-columns="))
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+	return nil
+}
+
+func (t *outputTable) numbered() bool {
+	return numberedOutput && len(t.headers) > 1 && t.headers[0] == "Number"
+}
+
+// json/yaml 之后输出编号和名称的对照表
+func (t *outputTable) writeNumbers(w io.Writer) {
+	if !t.numbered() {
+		return
+	}
+	index := &outputTable{headers: t.headers[:2], total: t.total}
+	for _, row := range t.rows {
+		index.rows = append(index.rows, row[:2])
+	}
+	index.writeTable(w, 2)
+}
+
+func (t *outputTable) writeTable(w io.Writer, columns int) {
+	table := tablewriter.NewWriter(w)
+	table.SetHeader(append(append([]string{}, t.headers...), t.wideHeaders...)[:columns])
+	rows := t.rows
+	if tableRowLimit > 0 && len(rows) > tableRowLimit {
+		rows = rows[:tableRowLimit]
+	}
+	for _, row := range rows {
+		table.Append(row[:columns])
+	}
+	table.Render()
+
+	total := t.total
+	if total < len(t.rows) {
+		total = len(t.rows)
+	}
+	switch {
+	case len(rows) < len(t.rows) && len(t.rows) < total:
+		fmt.Fprintf(w, "showing %d of %d matches (%d total), refine the search to see more\n", len(rows), len(t.rows), total)
+	case len(rows) < len(t.rows):
+		fmt.Fprintf(w, "showing %d of %d, enter a search to narrow down the list\n", len(rows), total)
+	case len(rows) < total:
+		fmt.Fprintf(w, "showing %d of %d\n", len(rows), total)
+	}
+}
+
+// 第 i 行对应的对象, 转换为 map 便于 JSONPath 查询
+func (t *outputTable) object(i int) (interface{}, error) {
+	if t.objects[i] == nil {
+		m := map[string]interface{}{}
+		for j, h := range append(append([]string{}, t.headers...), t.wideHeaders...) {
+			if j < len(t.rows[i]) {
+				m[h] = t.rows[i][j]
+			}
+		}
+		return m, nil
+	}
+	data, err := json.Marshal(t.objects[i])
+	if err != nil {
+		return nil, err
+	}
+	var obj interface{}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, err
+	}
+	if m, ok := obj.(map[string]interface{}); ok && t.kind != "" {
+		m["apiVersion"] = t.apiVersion
+		m["kind"] = t.kind
+	}
+	return obj, nil
+}
+
+// json/yaml 输出的内容, Kubernetes 对象输出为 List
+func (t *outputTable) document() interface{} {
+	items := make([]interface{}, 0, len(t.rows))
+	for i := range t.rows {
+		obj, err := t.object(i)
+		if err != nil {
+			obj = map[string]interface{}{"error": err.Error()}
+		}
+		items = append(items, obj)
+	}
+	if t.kind == "" {
+		return items
+	}
+	return map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "List",
+		"items":      items,
+	}
+}
+
+type customColumn struct {
+	header string
+	path   *jsonpath.JSONPath
+}
+
+// 解析 NAME:.metadata.name,STATUS:.status.phase
+func parseCustomColumns(spec string) ([]customColumn, error) {
+	if strings.TrimSpace(spec) == "" {
+		return nil, fmt.Errorf("custom-columns format requires a column spec, e.g. custom-columns=NAME:.metadata.name")
+	}
+	var columns []customColumn
+	for _, part := range strings.Split(spec, ",") {
+		header, expr, ok := strings.Cut(part, ":")
+		if !ok || header == "" || expr == "" {
+			return nil, fmt.Errorf("invalid custom column %q, expected NAME:JSONPATH", part)
+		}
+		// 与 kubectl 一样允许省略 {} 和开头的点
+		if !strings.HasPrefix(expr, "{") {
+			if !strings.HasPrefix(expr, ".") {
+				expr = "." + expr
+			}
+			expr = "{" + expr + "}"
+		}
+		path := jsonpath.New(header).AllowMissingKeys(true)
+		if err := path.Parse(expr); err != nil {
+			return nil, fmt.Errorf("invalid jsonpath %q for column %s: %v", expr, header, err)
+		}
+		columns = append(columns, customColumn{header: header, path: path})
+	}
+	return columns, nil
+}
+
+func (t *outputTable) writeCustomColumns(w io.Writer, spec string) error {
+	columns, err := parseCustomColumns(spec)
+	if err != nil {
+		return err
+	}
+	table := tablewriter.NewWriter(w)
+	var headers []string
+	if t.numbered() {
+		headers = append(headers, "NUMBER")
+	}
+	for _, c := range columns {
+		headers = append(headers, c.header)
+	}
+	table.SetHeader(headers)
+	for i := range t.rows {
+		obj, err := t.object(i)
+		if err != nil {
+			return err
+		}
+		var row []string
+		if t.numbered() {
+			row = append(row, t.rows[i][0])
+		}
+		for _, c := range columns {
+			var buf bytes.Buffer
+			if err := c.path.Execute(&buf, obj); err != nil {
+				return fmt.Errorf("column %s: %v", c.header, err)
+			}
+			value := buf.String()
+			if value == "" {
+				value = "<none>"
+			}
+			row = append(row, value)
+		}
+		table.Append(row)
+	}
+	table.Render()
+	return nil
+}
+
+// 交互模式中切换输出格式
+func handleOutputAction() {
+	format := *outputFormat
+	if strings.HasPrefix(format, "custom-columns=") {
+		format = "custom-columns"
+	}
+	if err := survey.AskOne(&survey.Select{
+		Message: fmt.Sprintf("choose output format (current: %s):", *outputFormat),
+		Options: outputFormats,
+		Default: format,
+	}, &format); err != nil {
+		return
+	}
+	if format == "custom-columns" {
+		spec := strings.TrimPrefix(*outputFormat, "custom-columns=")
+		if !strings.HasPrefix(*outputFormat, "custom-columns=") {
+			spec = "NAME:.metadata.name"
+		}
+		survey.AskOne(&survey.Input{
+			Message: "Enter columns as NAME:JSONPATH separated by commas: ",
+			Default: spec,
+		}, &spec)
+		format = "custom-columns=" + strings.TrimSpace(spec)
+	}
+	if err := validateOutputFormat(format); err != nil {
+		fmt.Println(err)
+		return
+	}
+	*outputFormat = format
+	fmt.Printf("Output format set to %s\n", format)
+}
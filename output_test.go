@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseCustomColumns(t *testing.T) {
+	tests := []struct {
+		spec    string
+		headers []string
+		wantErr bool
+	}{
+		{"NAME:.metadata.name", []string{"NAME"}, false},
+		{"NAME:metadata.name,PHASE:{.status.phase}", []string{"NAME", "PHASE"}, false},
+		{"NAME", nil, true},
+		{"NAME:.metadata[", nil, true},
+	}
+	for _, tt := range tests {
+		columns, err := parseCustomColumns(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseCustomColumns(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			continue
+		}
+		var headers []string
+		for _, c := range columns {
+			headers = append(headers, c.header)
+		}
+		if strings.Join(headers, ",") != strings.Join(tt.headers, ",") {
+			t.Errorf("parseCustomColumns(%q) headers = %v, want %v", tt.spec, headers, tt.headers)
+		}
+	}
+}
+
+func testPodTable() *outputTable {
+	pods := []v1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "api-0"}, Status: v1.PodStatus{Phase: v1.PodRunning, PodIP: "10.0.0.1"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "api-1"}, Status: v1.PodStatus{Phase: v1.PodPending}},
+	}
+	table := newOutputTable("Number", "Name", "Status").wide("IP").forKind("v1", "Pod")
+	table.total = 3
+	for i, pod := range pods {
+		table.append(&pods[i], strconv.Itoa(i), pod.Name, string(pod.Status.Phase), pod.Status.PodIP)
+	}
+	return table
+}
+
+func TestOutputTableWrite(t *testing.T) {
+	defer func(limit int, numbered bool) { tableRowLimit, numberedOutput = limit, numbered }(tableRowLimit, numberedOutput)
+
+	tests := []struct {
+		name     string
+		format   string
+		limit    int
+		numbered bool
+		contains []string
+		excludes []string
+	}{
+		{name: "table", format: "table", contains: []string{"NUMBER", "api-0", "Pending", "showing 2 of 3"}, excludes: []string{"10.0.0.1"}},
+		{name: "wide", format: "wide", contains: []string{"IP", "10.0.0.1"}},
+		{name: "row limit", format: "table", limit: 1, contains: []string{"api-0", "showing 1 of 2 matches (3 total)"}, excludes: []string{"api-1"}},
+		{name: "csv", format: "csv", contains: []string{"Number,Name,Status,IP\n", "0,api-0,Running,10.0.0.1\n", "1,api-1,Pending,\n"}},
+		{name: "json", format: "json", contains: []string{`"kind": "List"`, `"kind": "Pod"`, `"name": "api-1"`}, excludes: []string{"NUMBER"}},
+		{name: "json numbered", format: "json", numbered: true, contains: []string{`"kind": "List"`, "NUMBER", "|      1 | api-1 |"}},
+		{name: "custom columns", format: "custom-columns=NAME:.metadata.name,IP:.status.podIP", contains: []string{"api-0", "10.0.0.1", "<none>"}, excludes: []string{"NUMBER"}},
+	}
+	for _, tt := range tests {
+		tableRowLimit, numberedOutput = tt.limit, tt.numbered
+		var buf bytes.Buffer
+		if err := testPodTable().write(&buf, tt.format); err != nil {
+			t.Errorf("%s: write error = %v", tt.name, err)
+			continue
+		}
+		out := buf.String()
+		for _, s := range tt.contains {
+			if !strings.Contains(out, s) {
+				t.Errorf("%s: output missing %q:\n%s", tt.name, s, out)
+			}
+		}
+		for _, s := range tt.excludes {
+			if strings.Contains(out, s) {
+				t.Errorf("%s: output should not contain %q:\n%s", tt.name, s, out)
+			}
+		}
+	}
+
+	if err := testPodTable().write(&bytes.Buffer{}, "xml"); err == nil {
+		t.Error("write with unknown format should fail")
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// API 请求的默认超时时间, 可以通过 --request-timeout 或 requestTimeoutSeconds 配置
+const defaultRequestTimeout = 30 * time.Second
+
+var requestTimeoutFlag *time.Duration
+
+func init() {
+	requestTimeoutFlag = rootCmd.PersistentFlags().Duration("request-timeout", 0, "timeout for Kubernetes API requests, e.g. 10s (default 30s)")
+}
+
+func requestTimeout() time.Duration {
+	if *requestTimeoutFlag > 0 {
+		return *requestTimeoutFlag
+	}
+	if currentConfig.RequestTimeoutSeconds > 0 {
+		return time.Duration(currentConfig.RequestTimeoutSeconds) * time.Second
+	}
+	return defaultRequestTimeout
+}
+
+// 每个操作使用的 context, 超时或按 Ctrl+C 时取消, 取消后回到菜单而不是退出程序
+func requestContext() (context.Context, context.CancelFunc) {
+	return interruptContext(requestTimeout())
+}
+
+// 清理和后台任务使用的 context, 只有超时, 不响应 Ctrl+C
+func timeoutContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), requestTimeout())
+}
+
+func interruptContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt)
+	go func() {
+		defer signal.Stop(sigChan)
+		select {
+		case <-sigChan:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// 请求被取消或超时时给出说明
+func requestError(ctx context.Context, err error) error {
+	switch {
+	case errors.Is(ctx.Err(), context.Canceled):
+		return fmt.Errorf("request interrupted")
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return fmt.Errorf("request timed out after %s, the timeout can be changed with --request-timeout or requestTimeoutSeconds in ~/.kube-ui", requestTimeout())
+	}
+	return err
+}
+
+// 启动时检查 API server 是否可以访问, 并说明认证或网络错误的原因
+func checkAPIServer(host string) error {
+	ctx, cancel := requestContext()
+	defer cancel()
+	err := k8sClient.Discovery().RESTClient().Get().AbsPath("/version").Do(ctx).Error()
+	if err == nil || apierrors.IsForbidden(err) {
+		// 没有权限读取版本信息也说明已经连接并通过认证
+		return nil
+	}
+	if ctx.Err() != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("API server %s did not respond within %s, check the network, VPN or proxy settings", host, requestTimeout())
+		}
+		return requestError(ctx, err)
+	}
+	return fmt.Errorf("cannot connect to API server %s: %s", host, classifyConnectError(err))
+}
+
+func classifyConnectError(err error) string {
+	var dnsErr *net.DNSError
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var certInvalid x509.CertificateInvalidError
+	switch {
+	case apierrors.IsUnauthorized(err):
+		return "authentication failed, the credentials in the kubeconfig were rejected (expired token or certificate?)"
+	case errors.As(err, &dnsErr):
+		return fmt.Sprintf("can not resolve host %s", dnsErr.Name)
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return "connection refused, the API server is not listening on this address"
+	case errors.Is(err, syscall.EHOSTUNREACH), errors.Is(err, syscall.ENETUNREACH):
+		return "no route to the API server, check the network or VPN"
+	case errors.As(err, &unknownAuthority), errors.As(err, &certInvalid):
+		return "TLS certificate verification failed, check certificate-authority-data in the kubeconfig"
+	case errors.As(err, &hostnameErr):
+		return fmt.Sprintf("TLS certificate is not valid for this host: %v", hostnameErr)
+	case strings.Contains(err.Error(), "exec plugin"), strings.Contains(err.Error(), "getting credentials"):
+		return fmt.Sprintf("the credential plugin in the kubeconfig failed: %v", err)
+	}
+	return err.Error()
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func TestRequestError(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancelExpired := context.WithTimeout(context.Background(), -time.Second)
+	defer cancelExpired()
+	apiErr := errors.New("pods \"api-0\" not found")
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{"interrupted", cancelled, "request interrupted"},
+		{"timed out", expired, "request timed out after"},
+		{"api error", context.Background(), apiErr.Error()},
+	}
+	for _, tt := range tests {
+		if got := requestError(tt.ctx, apiErr); !strings.HasPrefix(got.Error(), tt.want) {
+			t.Errorf("%s: requestError() = %q, want prefix %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// 请求进行中按 Ctrl+C 只取消当前请求, 不会结束程序
+func TestRequestContextInterrupt(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	client, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	self, _ := os.FindProcess(os.Getpid())
+
+	ctx, cancel := requestContext()
+	defer cancel()
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		if err := self.Signal(os.Interrupt); err != nil {
+			cancel()
+		}
+	}()
+	err = client.Discovery().RESTClient().Get().AbsPath("/version").Do(ctx).Error()
+	if err == nil {
+		t.Fatal("request finished without error")
+	}
+	if got := requestError(ctx, err); got.Error() != "request interrupted" {
+		t.Errorf("requestError() = %q, want %q", got, "request interrupted")
+	}
+}
+
+func TestClassifyConnectError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{apierrors.NewUnauthorized("token expired"), "authentication failed"},
+		{fmt.Errorf("Get: %w", &net.DNSError{Name: "k8s.example.com", Err: "no such host"}), "can not resolve host k8s.example.com"},
+		{fmt.Errorf("dial: %w", &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}), "connection refused"},
+		{fmt.Errorf("dial: %w", syscall.EHOSTUNREACH), "no route to the API server"},
+		{fmt.Errorf("tls: %w", x509.UnknownAuthorityError{}), "TLS certificate verification failed"},
+		{fmt.Errorf("tls: %w", x509.HostnameError{Certificate: &x509.Certificate{}, Host: "10.0.0.1"}), "TLS certificate is not valid for this host"},
+		{errors.New("getting credentials: exec: executable aws not found"), "the credential plugin in the kubeconfig failed"},
+		{errors.New("something else"), "something else"},
+	}
+	for _, tt := range tests {
+		if got := classifyConnectError(tt.err); !strings.HasPrefix(got, tt.want) {
+			t.Errorf("classifyConnectError(%v) = %q, want prefix %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestCheckAPIServer(t *testing.T) {
+	defer func(client *kubernetes.Clientset, flag time.Duration) {
+		k8sClient, *requestTimeoutFlag = client, flag
+	}(k8sClient, *requestTimeoutFlag)
+	*requestTimeoutFlag = 500 * time.Millisecond
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		want    string
+	}{
+		{"ok", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"major":"1","minor":"31","gitVersion":"v1.31.0"}`)
+		}, ""},
+		{"forbidden", func(w http.ResponseWriter, r *http.Request) {
+			writeStatus(w, apierrors.NewForbidden(schema.GroupResource{}, "", errors.New("no access")))
+		}, ""},
+		{"unauthorized", func(w http.ResponseWriter, r *http.Request) {
+			writeStatus(w, apierrors.NewUnauthorized("expired"))
+		}, "authentication failed"},
+		{"slow", func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+		}, "did not respond within 500ms"},
+	}
+	for _, tt := range tests {
+		server := httptest.NewServer(tt.handler)
+		var err error
+		if k8sClient, err = kubernetes.NewForConfig(&rest.Config{Host: server.URL}); err != nil {
+			t.Fatal(err)
+		}
+		err = checkAPIServer(server.URL)
+		server.Close()
+		switch {
+		case tt.want == "" && err != nil:
+			t.Errorf("%s: checkAPIServer() error = %v", tt.name, err)
+		case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+			t.Errorf("%s: checkAPIServer() error = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func writeStatus(w http.ResponseWriter, err *apierrors.StatusError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(int(err.ErrStatus.Code))
+	fmt.Fprintf(w, `{"kind":"Status","apiVersion":"v1","status":"Failure","message":%q,"reason":%q,"code":%d}`,
+		err.ErrStatus.Message, err.ErrStatus.Reason, err.ErrStatus.Code)
+}
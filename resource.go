@@ -0,0 +1,174 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	restConfig    *rest.Config
+	dynamicClient dynamic.Interface
+	restMapper    meta.RESTMapper
+)
+
+var (
+	podsGVR        = schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	servicesGVR    = schema.GroupVersionResource{Version: "v1", Resource: "services"}
+	configMapsGVR  = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	pvcsGVR        = schema.GroupVersionResource{Version: "v1", Resource: "persistentvolumeclaims"}
+	pvsGVR         = schema.GroupVersionResource{Version: "v1", Resource: "persistentvolumes"}
+	deploymentsGVR = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+)
+
+// 指向集群中某个对象, 集群级资源的 Namespace 为空
+type resourceRef struct {
+	GVR       schema.GroupVersionResource
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+func (r resourceRef) String() string {
+	return strings.ToLower(r.Kind) + "/" + r.Name
+}
+
+func (r resourceRef) client() dynamic.ResourceInterface {
+	if r.Namespace == "" {
+		return dynamicClient.Resource(r.GVR)
+	}
+	return dynamicClient.Resource(r.GVR).Namespace(r.Namespace)
+}
+
+func podRef(name string) resourceRef {
+	return resourceRef{GVR: podsGVR, Kind: "Pod", Namespace: *namespace, Name: name}
+}
+
+func deploymentRef(name string) resourceRef {
+	return resourceRef{GVR: deploymentsGVR, Kind: "Deployment", Namespace: *namespace, Name: name}
+}
+
+func serviceRef(name string) resourceRef {
+	return resourceRef{GVR: servicesGVR, Kind: "Service", Namespace: *namespace, Name: name}
+}
+
+func configMapRef(name string) resourceRef {
+	return resourceRef{GVR: configMapsGVR, Kind: "ConfigMap", Namespace: *namespace, Name: name}
+}
+
+func pvcRef(name string) resourceRef {
+	return resourceRef{GVR: pvcsGVR, Kind: "PersistentVolumeClaim", Namespace: *namespace, Name: name}
+}
+
+func pvRef(name string) resourceRef {
+	return resourceRef{GVR: pvsGVR, Kind: "PersistentVolume", Name: name}
+}
+
+// 初始化动态客户端和 RESTMapper, 用于处理任意类型的资源
+func initDynamicClient(config *rest.Config) error {
+	var err error
+	restConfig = config
+	dynamicClient, err = dynamic.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+	restMapper = restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(k8sClient.Discovery()))
+	return nil
+}
+
+// 根据对象的 apiVersion/kind 找到对应的资源, 未指定 namespace 的命名空间级对象使用当前 namespace
+func refForObject(obj *unstructured.Unstructured) (resourceRef, error) {
+	gvk := obj.GroupVersionKind()
+	mapping, err := restMapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return resourceRef{}, fmt.Errorf("unknown resource type %s: %v", gvk.String(), err)
+	}
+	ref := resourceRef{GVR: mapping.Resource, Kind: gvk.Kind, Name: obj.GetName()}
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		ref.Namespace = obj.GetNamespace()
+		if ref.Namespace == "" {
+			ref.Namespace = *namespace
+		}
+	}
+	return ref, nil
+}
+
+func getLiveObject(ref resourceRef) (*unstructured.Unstructured, error) {
+	ctx, cancel := requestContext()
+	defer cancel()
+	obj, err := ref.client().Get(ctx, ref.Name, metav1.GetOptions{})
+	return obj, requestError(ctx, err)
+}
+
+// 删除对比和编辑时无意义的字段
+func stripNoiseFields(obj *unstructured.Unstructured) {
+	obj.SetManagedFields(nil)
+	obj.SetResourceVersion("")
+	obj.SetUID("")
+	obj.SetCreationTimestamp(metav1.Time{})
+	obj.SetGeneration(0)
+	obj.SetSelfLink("")
+	unstructured.RemoveNestedField(obj.Object, "status")
+	unstructured.RemoveNestedField(obj.Object, "metadata", "creationTimestamp")
+}
+
+func objectToYAML(obj *unstructured.Unstructured) (string, error) {
+	data, err := yaml.Marshal(obj.Object)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// 读取本地多文档 YAML/JSON 文件, 展开 List 类型
+func readManifestObjects(path string) ([]*unstructured.Unstructured, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var objects []*unstructured.Unstructured
+	decoder := utilyaml.NewYAMLOrJSONDecoder(f, 4096)
+	for {
+		obj := &unstructured.Unstructured{}
+		if err := decoder.Decode(&obj.Object); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("error parsing %s: %v", path, err)
+		}
+		// 空文档
+		if len(obj.Object) == 0 {
+			continue
+		}
+		if obj.IsList() {
+			list, err := obj.ToList()
+			if err != nil {
+				return nil, err
+			}
+			for i := range list.Items {
+				objects = append(objects, &list.Items[i])
+			}
+			continue
+		}
+		if obj.GetKind() == "" || obj.GetAPIVersion() == "" {
+			return nil, fmt.Errorf("object in %s is missing apiVersion or kind", path)
+		}
+		objects = append(objects, obj)
+	}
+	return objects, nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadManifestObjects(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+		wantErr bool
+	}{
+		{"multi document with empty", "---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n---\n---\napiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: b\n", []string{"ConfigMap/a", "Deployment/b"}, false},
+		{"list", "apiVersion: v1\nkind: List\nitems:\n- apiVersion: v1\n  kind: Service\n  metadata:\n    name: s1\n- apiVersion: v1\n  kind: Service\n  metadata:\n    name: s2\n", []string{"Service/s1", "Service/s2"}, false},
+		{"missing kind", "apiVersion: v1\nmetadata:\n  name: a\n", nil, true},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "manifest.yaml")
+		if err := os.WriteFile(path, []byte(tt.content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		objects, err := readManifestObjects(path)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: readManifestObjects error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		var got []string
+		for _, obj := range objects {
+			got = append(got, obj.GetKind()+"/"+obj.GetName())
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: readManifestObjects = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
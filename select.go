@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// 按完整名称, 编号或唯一的名称前缀选择对象, 编号对应最近一次输出的完整表格
+// 完整名称优先, 名称为数字的对象 (例如 ConfigMap 2024) 也可以按名称选择
+func selectListItem[T any](items []T, input string, name func(T) string) (T, bool) {
+	var zero T
+	if input == "" {
+		return zero, false
+	}
+	for _, item := range items {
+		if name(item) == input {
+			return item, true
+		}
+	}
+	if n, err := strconv.Atoi(input); err == nil {
+		if n >= 0 && n < len(items) {
+			return items[n], true
+		}
+		return zero, false
+	}
+	var matched []T
+	for _, item := range items {
+		if strings.HasPrefix(name(item), input) {
+			matched = append(matched, item)
+		}
+	}
+	if len(matched) == 1 {
+		return matched[0], true
+	}
+	return zero, false
+}
+
+// 执行操作前按名称重新获取对象, 对象已删除或被同名的新对象替换时拒绝操作
+// 对象在列出之后被修改过时 modified 为 true, into 中是最新的对象
+func resolveObject(ref resourceRef, uid types.UID, resourceVersion string, into interface{}) (bool, error) {
+	live, err := getLiveObject(ref)
+	if apierrors.IsNotFound(err) {
+		return false, fmt.Errorf("%s no longer exists, refusing to act on it", ref)
+	}
+	if err != nil {
+		return false, fmt.Errorf("Error getting %s: %v", ref, err)
+	}
+	if live.GetUID() != uid {
+		return false, fmt.Errorf("%s was deleted and recreated after the list was loaded, refusing to act on it, please select it again", ref)
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(live.Object, into); err != nil {
+		return false, err
+	}
+	return live.GetResourceVersion() != resourceVersion, nil
+}
+
+// 列表中选择的对象, 被修改过时拒绝操作, 避免操作没有看到过的版本
+func resolveSelected[T any](ref resourceRef, uid types.UID, resourceVersion string) (*T, error) {
+	obj := new(T)
+	modified, err := resolveObject(ref, uid, resourceVersion, obj)
+	if err != nil {
+		return nil, err
+	}
+	if modified {
+		return nil, fmt.Errorf("%s was modified after the list was loaded, refusing to act on it, please list and select it again", ref)
+	}
+	return obj, nil
+}
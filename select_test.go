@@ -0,0 +1,27 @@
+package main
+
+import "testing"
+
+func TestSelectListItem(t *testing.T) {
+	items := []string{"api-0", "api-1", "worker-0", "2024", "7"}
+	tests := []struct {
+		input string
+		want  string
+		ok    bool
+	}{
+		{"", "", false},
+		{"1", "api-1", true},
+		{"7", "7", true},
+		{"4", "7", true},
+		{"5", "", false},
+		{"api-1", "api-1", true},
+		{"work", "worker-0", true},
+		{"api", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := selectListItem(items, tt.input, func(s string) string { return s })
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("selectListItem(%q) = %q, %v, want %q, %v", tt.input, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// 表格的排序列, compare 按列的实际类型比较: 数量, 时间, 数字或字符串
+type sortColumn[T any] struct {
+	name    string
+	compare func(a, b *T) int
+}
+
+// 当前会话中每个资源列表的排序, 例如 "-restarts", 未设置时使用配置中的 sort
+var listSorts = map[string]string{}
+
+func byString[T any](value func(*T) string) func(a, b *T) int {
+	return func(a, b *T) int { return strings.Compare(value(a), value(b)) }
+}
+
+func byNumber[T any](value func(*T) int64) func(a, b *T) int {
+	return func(a, b *T) int { return cmp.Compare(value(a), value(b)) }
+}
+
+// 按年龄排序, 新创建的对象年龄小, 排在前面
+func byAge[T any](created func(*T) time.Time) func(a, b *T) int {
+	return func(a, b *T) int { return created(b).Compare(created(a)) }
+}
+
+func byQuantity[T any](value func(*T) *resource.Quantity) func(a, b *T) int {
+	return func(a, b *T) int { return value(a).Cmp(*value(b)) }
+}
+
+// IP 按地址比较, 空地址排在最后
+func byIP[T any](value func(*T) string) func(a, b *T) int {
+	return func(a, b *T) int {
+		ipA, errA := netip.ParseAddr(value(a))
+		ipB, errB := netip.ParseAddr(value(b))
+		switch {
+		case errA != nil && errB != nil:
+			return strings.Compare(value(a), value(b))
+		case errA != nil:
+			return 1
+		case errB != nil:
+			return -1
+		}
+		return ipA.Compare(ipB)
+	}
+}
+
+func podRestarts(pod *v1.Pod) int32 {
+	var restarts int32
+	for _, status := range pod.Status.ContainerStatuses {
+		restarts += status.RestartCount
+	}
+	return restarts
+}
+
+var podSortColumns = []sortColumn[v1.Pod]{
+	{"name", byString(func(p *v1.Pod) string { return p.Name })},
+	{"status", byString(func(p *v1.Pod) string { return string(p.Status.Phase) })},
+	{"restarts", byNumber(func(p *v1.Pod) int64 { return int64(podRestarts(p)) })},
+	{"age", byAge(func(p *v1.Pod) time.Time {
+		if p.Status.StartTime != nil {
+			return p.Status.StartTime.Time
+		}
+		return p.CreationTimestamp.Time
+	})},
+	{"ip", byIP(func(p *v1.Pod) string { return p.Status.PodIP })},
+	{"node", byString(func(p *v1.Pod) string { return p.Spec.NodeName })},
+}
+
+var deploymentSortColumns = []sortColumn[appsv1.Deployment]{
+	{"name", byString(func(d *appsv1.Deployment) string { return d.Name })},
+	{"replicas", byNumber(func(d *appsv1.Deployment) int64 { return int64(d.Status.Replicas) })},
+	{"age", byAge(func(d *appsv1.Deployment) time.Time { return d.CreationTimestamp.Time })},
+}
+
+var serviceSortColumns = []sortColumn[v1.Service]{
+	{"name", byString(func(s *v1.Service) string { return s.Name })},
+	{"type", byString(func(s *v1.Service) string { return string(s.Spec.Type) })},
+	{"cluster-ip", byIP(func(s *v1.Service) string { return s.Spec.ClusterIP })},
+	{"age", byAge(func(s *v1.Service) time.Time { return s.CreationTimestamp.Time })},
+}
+
+var configMapSortColumns = []sortColumn[v1.ConfigMap]{
+	{"name", byString(func(c *v1.ConfigMap) string { return c.Name })},
+	{"data", byNumber(func(c *v1.ConfigMap) int64 { return int64(len(c.Data)) })},
+	{"binarydata", byNumber(func(c *v1.ConfigMap) int64 { return int64(len(c.BinaryData)) })},
+	{"size", byNumber(func(c *v1.ConfigMap) int64 { return int64(configMapSize(*c)) })},
+	{"age", byAge(func(c *v1.ConfigMap) time.Time { return c.CreationTimestamp.Time })},
+}
+
+var pvcSortColumns = []sortColumn[v1.PersistentVolumeClaim]{
+	{"name", byString(func(p *v1.PersistentVolumeClaim) string { return p.Name })},
+	{"status", byString(func(p *v1.PersistentVolumeClaim) string { return string(p.Status.Phase) })},
+	{"storageclass", byString(func(p *v1.PersistentVolumeClaim) string {
+		if p.Spec.StorageClassName == nil {
+			return ""
+		}
+		return *p.Spec.StorageClassName
+	})},
+	{"capacity", byQuantity(func(p *v1.PersistentVolumeClaim) *resource.Quantity { return p.Status.Capacity.Storage() })},
+	{"age", byAge(func(p *v1.PersistentVolumeClaim) time.Time { return p.CreationTimestamp.Time })},
+}
+
+var pvSortColumns = []sortColumn[v1.PersistentVolume]{
+	{"name", byString(func(p *v1.PersistentVolume) string { return p.Name })},
+	{"status", byString(func(p *v1.PersistentVolume) string { return string(p.Status.Phase) })},
+	{"storageclass", byString(func(p *v1.PersistentVolume) string { return p.Spec.StorageClassName })},
+	{"capacity", byQuantity(func(p *v1.PersistentVolume) *resource.Quantity { return p.Spec.Capacity.Storage() })},
+	{"age", byAge(func(p *v1.PersistentVolume) time.Time { return p.CreationTimestamp.Time })},
+}
+
+func resourceSortColumns[T any](resource string) []sortColumn[T] {
+	var columns interface{}
+	switch resource {
+	case "pods":
+		columns = podSortColumns
+	case "deployments":
+		columns = deploymentSortColumns
+	case "svc":
+		columns = serviceSortColumns
+	case "configmap":
+		columns = configMapSortColumns
+	case "pvc":
+		columns = pvcSortColumns
+	case "pv":
+		columns = pvSortColumns
+	}
+	c, _ := columns.([]sortColumn[T])
+	return c
+}
+
+func sortColumnNames[T any](resource string) []string {
+	var names []string
+	for _, c := range resourceSortColumns[T](resource) {
+		names = append(names, c.name)
+	}
+	return names
+}
+
+// 解析 "age" 或 "-restarts", 前缀 - 表示倒序
+func findSortColumn[T any](resource, spec string) (sortColumn[T], bool, error) {
+	name, desc := strings.CutPrefix(strings.TrimSpace(spec), "-")
+	name = strings.ToLower(name)
+	for _, c := range resourceSortColumns[T](resource) {
+		if c.name == name {
+			return c, desc, nil
+		}
+	}
+	return sortColumn[T]{}, false, fmt.Errorf("can not sort %s by %q, available columns: %s", resource, name, strings.Join(sortColumnNames[T](resource), ", "))
+}
+
+func listSort(resource string) string {
+	if spec, ok := listSorts[resource]; ok {
+		return spec
+	}
+	return currentConfig.Sort[resource]
+}
+
+// 按当前排序排列对象, 相同的值保持原来的顺序; 排序无效时返回错误并保持原来的顺序
+func sortItems[T any](resource string, items []T) error {
+	spec := listSort(resource)
+	if spec == "" {
+		return nil
+	}
+	column, desc, err := findSortColumn[T](resource, spec)
+	if err != nil {
+		return err
+	}
+	slices.SortStableFunc(items, func(a, b T) int {
+		if desc {
+			return column.compare(&b, &a)
+		}
+		return column.compare(&a, &b)
+	})
+	return nil
+}
+
+// 列表中的 sort 命令: "sort" 显示可用的列, "sort age" 或 "sort -restarts" 设置排序
+func handleSortCommand[T any](resource, input string) {
+	spec := strings.TrimSpace(strings.TrimPrefix(input, "sort"))
+	if spec == "" {
+		current := listSort(resource)
+		if current == "" {
+			current = "name"
+		}
+		fmt.Printf("Sorted by %s, available columns: %s (prefix with - for descending order)\n", current, strings.Join(sortColumnNames[T](resource), ", "))
+		return
+	}
+	if _, _, err := findSortColumn[T](resource, spec); err != nil {
+		fmt.Println(err)
+		return
+	}
+	listSorts[resource] = spec
+}
+
+// 检查配置中的默认排序
+func validateConfigSorts() {
+	for resource, spec := range currentConfig.Sort {
+		var err error
+		switch resource {
+		case "pods":
+			_, _, err = findSortColumn[v1.Pod](resource, spec)
+		case "deployments":
+			_, _, err = findSortColumn[appsv1.Deployment](resource, spec)
+		case "svc":
+			_, _, err = findSortColumn[v1.Service](resource, spec)
+		case "configmap":
+			_, _, err = findSortColumn[v1.ConfigMap](resource, spec)
+		case "pvc":
+			_, _, err = findSortColumn[v1.PersistentVolumeClaim](resource, spec)
+		case "pv":
+			_, _, err = findSortColumn[v1.PersistentVolume](resource, spec)
+		default:
+			err = fmt.Errorf("unknown resource %q, expected one of pods, deployments, svc, configmap, pvc, pv", resource)
+		}
+		if err != nil {
+			fmt.Printf("\033[1;33mIgnoring sort in ~/.kube-ui: %v\033[0m\n", err)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSortItems(t *testing.T) {
+	defer func() { listSorts = map[string]string{} }()
+	pods := func() []v1.Pod {
+		restarts := []int32{3, 0, 7}
+		var pods []v1.Pod
+		for i, name := range []string{"api-1", "api-0", "api-2"} {
+			pods = append(pods, v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: name},
+				Status:     v1.PodStatus{ContainerStatuses: []v1.ContainerStatus{{RestartCount: restarts[i]}}},
+			})
+		}
+		return pods
+	}
+	tests := []struct {
+		spec    string
+		want    []string
+		wantErr bool
+	}{
+		{"", []string{"api-1", "api-0", "api-2"}, false},
+		{"name", []string{"api-0", "api-1", "api-2"}, false},
+		{"-restarts", []string{"api-2", "api-1", "api-0"}, false},
+		{"Restarts", []string{"api-0", "api-1", "api-2"}, false},
+		{"capacity", []string{"api-1", "api-0", "api-2"}, true},
+	}
+	for _, tt := range tests {
+		listSorts["pods"] = tt.spec
+		items := pods()
+		err := sortItems("pods", items)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("sortItems(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+		}
+		var got []string
+		for _, p := range items {
+			got = append(got, p.Name)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("sortItems(%q) = %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+}
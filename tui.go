@@ -0,0 +1,786 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/AlecAivazis/survey/v2"
+	"golang.org/x/term"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/client-go/tools/cache"
+)
+
+// 全屏模式: 方向键选择, 输入过滤, 快捷键操作, 列表通过 informer 实时刷新
+
+var tuiMode *bool
+
+func init() {
+	tuiMode = rootCmd.Flags().Bool("tui", false, "start the full-screen terminal UI")
+}
+
+// 快捷键, 执行时退出全屏, 复用交互模式的处理函数
+type tuiHotkey struct {
+	key   rune
+	label string
+	wait  bool // 执行后等待回车再回到全屏, 便于查看输出
+	run   func(obj interface{})
+}
+
+type tuiResource struct {
+	name    string
+	ref     func(name string) resourceRef
+	open    func(obj interface{})
+	details func(obj interface{}) []string
+	hotkeys []tuiHotkey
+}
+
+func objectName(obj interface{}) string {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return ""
+	}
+	return accessor.GetName()
+}
+
+func objectKey(obj interface{}) string {
+	key, _ := cache.MetaNamespaceKeyFunc(obj)
+	return key
+}
+
+// 所有资源共有的快捷键
+func commonHotkeys(kind string, ref func(name string) resourceRef) []tuiHotkey {
+	return []tuiHotkey{
+		{key: 'y', label: "yaml", wait: true, run: func(obj interface{}) {
+			execCommand("get", kind, objectName(obj), "-o", "yaml")
+		}},
+		{key: 'E', label: "edit", run: func(obj interface{}) {
+			handleEditAction(ref(objectName(obj)))
+		}},
+		{key: 'R', label: "restore", wait: true, run: func(obj interface{}) {
+			handleRestoreAction(ref(objectName(obj)))
+		}},
+	}
+}
+
+func tuiResources() []tuiResource {
+	return []tuiResource{
+		{
+			name:    "pods",
+			ref:     podRef,
+			open:    func(obj interface{}) { handlePodAction(line, *obj.(*v1.Pod)) },
+			details: func(obj interface{}) []string { return podDetails(obj.(*v1.Pod)) },
+			hotkeys: append([]tuiHotkey{
+				{key: 'l', label: "logs", wait: true, run: func(obj interface{}) { podLogs(objectName(obj), "", false) }},
+				{key: 'f', label: "follow", wait: true, run: func(obj interface{}) { podLogs(objectName(obj), "", true) }},
+				{key: 's', label: "shell", run: func(obj interface{}) { podShell(objectName(obj), "") }},
+				{key: 'e', label: "events", wait: true, run: func(obj interface{}) { printPodEvents(*obj.(*v1.Pod)) }},
+				{key: 'd', label: "delete", wait: true, run: func(obj interface{}) {
+					if tuiConfirm(fmt.Sprintf("Delete pod %s?", objectName(obj))) {
+						deletePod(objectName(obj), obj.(*v1.Pod).UID)
+					}
+				}},
+			}, commonHotkeys("pod", podRef)...),
+		},
+		{
+			name:    "deployments",
+			ref:     deploymentRef,
+			open:    func(obj interface{}) { handleDeploymentAction(line, *obj.(*appsv1.Deployment)) },
+			details: func(obj interface{}) []string { return deploymentDetails(obj.(*appsv1.Deployment)) },
+			hotkeys: append([]tuiHotkey{
+				{key: 's', label: "scale", wait: true, run: func(obj interface{}) { handleDeploymentScaleNumAction(line, *obj.(*appsv1.Deployment)) }},
+			}, commonHotkeys("deployment", deploymentRef)...),
+		},
+		{
+			name:    "svc",
+			ref:     serviceRef,
+			open:    func(obj interface{}) { handleSvcAction(line, *obj.(*v1.Service)) },
+			details: func(obj interface{}) []string { return serviceDetails(obj.(*v1.Service)) },
+			hotkeys: append([]tuiHotkey{
+				{key: 'f', label: "forward", run: func(obj interface{}) {
+					ports, _ := line.Prompt("please enter forward ports, example: \"8080:80 9090:90\" ")
+					forwardPorts("svc/"+objectName(obj), strings.Fields(ports))
+				}},
+			}, commonHotkeys("svc", serviceRef)...),
+		},
+		{
+			name:    "configmap",
+			ref:     configMapRef,
+			open:    func(obj interface{}) { handleConfigMapAction(line, *obj.(*v1.ConfigMap)) },
+			details: func(obj interface{}) []string { return configMapDetails(obj.(*v1.ConfigMap)) },
+			hotkeys: append([]tuiHotkey{
+				{key: 'k', label: "keys", run: func(obj interface{}) { handleConfigMapKeysAction(line, objectName(obj)) }},
+			}, commonHotkeys("configmap", configMapRef)...),
+		},
+		{
+			name:    "pvc",
+			ref:     pvcRef,
+			open:    func(obj interface{}) { handlePvcAction(line, *obj.(*v1.PersistentVolumeClaim)) },
+			details: func(obj interface{}) []string { return pvcDetails(obj.(*v1.PersistentVolumeClaim)) },
+			hotkeys: commonHotkeys("pvc", pvcRef),
+		},
+		{
+			name:    "pv",
+			ref:     pvRef,
+			open:    func(obj interface{}) { handlePvAction(line, *obj.(*v1.PersistentVolume)) },
+			details: func(obj interface{}) []string { return pvDetails(obj.(*v1.PersistentVolume)) },
+			hotkeys: commonHotkeys("pv", pvRef),
+		},
+	}
+}
+
+func tuiConfirm(message string) bool {
+	confirm := false
+	survey.AskOne(&survey.Confirm{Message: message, Default: false}, &confirm)
+	return confirm
+}
+
+type tui struct {
+	fd        int
+	oldState  *term.State
+	resources []tuiResource
+	current   int
+
+	informer     cache.SharedIndexInformer
+	registration cache.ResourceEventHandlerRegistration
+	changed      chan struct{}
+	// 正在加载的资源, 加载期间 Ctrl+C 取消加载而不是退出
+	loading context.CancelFunc
+	loaded  chan tuiLoad
+
+	filter      string
+	filtering   bool
+	table       *outputTable
+	cursor      int
+	offset      int
+	selectedKey string
+	message     string
+}
+
+func (t *tui) enter() error {
+	state, err := term.MakeRaw(t.fd)
+	if err != nil {
+		return err
+	}
+	t.oldState = state
+	// 使用备用屏幕并隐藏光标
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+	return nil
+}
+
+func (t *tui) leave() {
+	fmt.Print("\x1b[?25h\x1b[?1049l")
+	if t.oldState != nil {
+		term.Restore(t.fd, t.oldState)
+		t.oldState = nil
+	}
+}
+
+// 退出全屏执行操作, 完成后恢复
+func (t *tui) suspend(fn func(), wait bool) {
+	t.leave()
+	fn()
+	if wait {
+		line.Prompt("Press Enter to return to kube-ui ")
+	}
+	if err := t.enter(); err != nil {
+		t.message = err.Error()
+	}
+}
+
+// 资源加载的结果
+type tuiLoad struct {
+	informer cache.SharedIndexInformer
+	err      error
+}
+
+// 切换资源类型, 在后台加载, 加载期间继续读取按键
+func (t *tui) switchResource(i int) {
+	t.unwatch()
+	t.current = (i + len(t.resources)) % len(t.resources)
+	t.cursor, t.offset, t.selectedKey = 0, 0, ""
+	t.table = nil
+	name := t.resources[t.current].name
+	t.message = fmt.Sprintf("Loading %s... (Ctrl+C to cancel)", name)
+	t.draw()
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout())
+	loaded := make(chan tuiLoad, 1)
+	t.loading, t.loaded = cancel, loaded
+	ns := *namespace
+	go func() {
+		informer, err := resourceInformerContext(ctx, ns, name)
+		loaded <- tuiLoad{informer: informer, err: err}
+	}()
+}
+
+// 加载完成后注册 informer 事件用于刷新
+func (t *tui) finishLoad(l tuiLoad) {
+	t.loading()
+	t.loading, t.loaded = nil, nil
+	if l.err != nil {
+		t.message = l.err.Error()
+		return
+	}
+	informer := l.informer
+	notify := func() {
+		select {
+		case t.changed <- struct{}{}:
+		default:
+		}
+	}
+	registration, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { notify() },
+		UpdateFunc: func(oldObj, newObj interface{}) { notify() },
+		DeleteFunc: func(obj interface{}) { notify() },
+	})
+	if err != nil {
+		t.message = err.Error()
+	} else {
+		t.informer, t.registration = informer, registration
+		t.message = ""
+	}
+}
+
+func (t *tui) unwatch() {
+	if t.loading != nil {
+		t.loading()
+		t.loading, t.loaded = nil, nil
+	}
+	if t.informer != nil && t.registration != nil {
+		t.informer.RemoveEventHandler(t.registration)
+	}
+	t.informer, t.registration = nil, nil
+}
+
+// 重新生成行, 按对象保持选中行, 对象被删除时停在原来的位置
+func (t *tui) refresh() {
+	if t.informer == nil {
+		t.table = nil
+		return
+	}
+	t.table = informerTable(t.resources[t.current].name, sortedInformerObjects(t.informer), t.filter)
+	rows := len(t.table.rows)
+	for i, obj := range t.table.objects {
+		if t.selectedKey != "" && objectKey(obj) == t.selectedKey {
+			t.cursor = i
+			return
+		}
+	}
+	if t.cursor >= rows {
+		t.cursor = rows - 1
+	}
+	if t.cursor < 0 {
+		t.cursor = 0
+	}
+	t.selectedKey = ""
+	if rows > 0 {
+		t.selectedKey = objectKey(t.table.objects[t.cursor])
+	}
+}
+
+func (t *tui) selected() interface{} {
+	if t.table == nil || t.cursor >= len(t.table.rows) {
+		return nil
+	}
+	return t.table.objects[t.cursor]
+}
+
+// 执行操作前从 API server 重新获取选中的对象, 对象已删除或被替换时只显示提示
+func (t *tui) resolveSelected() interface{} {
+	obj := t.selected()
+	if obj == nil {
+		return nil
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return nil
+	}
+	fresh := reflect.New(reflect.TypeOf(obj).Elem()).Interface()
+	ref := t.resources[t.current].ref(accessor.GetName())
+	modified, err := resolveObject(ref, accessor.GetUID(), accessor.GetResourceVersion(), fresh)
+	if err != nil {
+		t.message = err.Error()
+		return nil
+	}
+	// 列表会随缓存更新, 再按一次时操作的就是显示出来的最新版本
+	if modified {
+		t.message = fmt.Sprintf("%s was modified since it was displayed, check it and press the key again", ref)
+		return nil
+	}
+	return fresh
+}
+
+func (t *tui) move(delta int) {
+	if t.table == nil || len(t.table.rows) == 0 {
+		return
+	}
+	t.cursor += delta
+	if t.cursor < 0 {
+		t.cursor = 0
+	}
+	if t.cursor >= len(t.table.rows) {
+		t.cursor = len(t.table.rows) - 1
+	}
+	t.selectedKey = objectKey(t.table.objects[t.cursor])
+}
+
+// 处理按键, 返回 false 时退出
+func (t *tui) handleKey(k tuiKey) bool {
+	if k.name == "ctrl-c" {
+		// 加载很慢时取消加载, 可以切换到其他资源或命名空间
+		if t.loading != nil {
+			t.loading()
+			return true
+		}
+		return false
+	}
+	t.message = ""
+	_, height := t.size()
+	page := height / 2
+
+	if t.filtering {
+		switch k.name {
+		case "esc":
+			t.filter, t.filtering = "", false
+		case "enter":
+			t.filtering = false
+		case "up", "down":
+			t.filtering = false
+			return t.handleKey(k)
+		case "backspace":
+			if t.filter != "" {
+				_, size := utf8.DecodeLastRuneInString(t.filter)
+				t.filter = t.filter[:len(t.filter)-size]
+			}
+		case "":
+			t.filter += string(k.r)
+		}
+		t.cursor, t.selectedKey = 0, ""
+		return true
+	}
+
+	switch k.name {
+	case "up":
+		t.move(-1)
+	case "down":
+		t.move(1)
+	case "pgup":
+		t.move(-page)
+	case "pgdn":
+		t.move(page)
+	case "home":
+		t.move(-len(t.table.rowsOrEmpty()))
+	case "end":
+		t.move(len(t.table.rowsOrEmpty()))
+	case "tab", "right":
+		t.switchResource(t.current + 1)
+	case "backtab", "left":
+		t.switchResource(t.current - 1)
+	case "esc":
+		t.filter = ""
+	case "enter":
+		if obj := t.resolveSelected(); obj != nil {
+			t.suspend(func() { t.resources[t.current].open(obj) }, false)
+		}
+	case "":
+		switch k.r {
+		case 'q':
+			return false
+		case '/':
+			t.filtering = true
+		case 'N':
+			t.suspend(func() {
+				if err := selectNamespace(); err != nil {
+					fmt.Println(err)
+				}
+			}, false)
+			t.filter = ""
+			t.switchResource(t.current)
+		default:
+			for _, h := range t.resources[t.current].hotkeys {
+				if h.key != k.r {
+					continue
+				}
+				if obj := t.resolveSelected(); obj != nil {
+					t.suspend(func() { h.run(obj) }, h.wait)
+				}
+				break
+			}
+		}
+	}
+	return true
+}
+
+func (t *outputTable) rowsOrEmpty() [][]string {
+	if t == nil {
+		return nil
+	}
+	return t.rows
+}
+
+func (t *tui) size() (int, int) {
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || width <= 0 || height <= 0 {
+		return 80, 24
+	}
+	return width, height
+}
+
+func (t *tui) draw() {
+	if t.oldState == nil {
+		return
+	}
+	t.refresh()
+	width, height := t.size()
+	res := t.resources[t.current]
+
+	var lines []string
+	// 标题和资源标签
+	var tabs []string
+	for i, r := range t.resources {
+		if i == t.current {
+			tabs = append(tabs, "\x1b[7m "+r.name+" \x1b[0m")
+		} else {
+			tabs = append(tabs, " "+r.name+" ")
+		}
+	}
+	title := currentConfig.Name
+	if title == "" {
+		title = auditContext
+	}
+	lines = append(lines, fmt.Sprintf("\x1b[1mkube-ui\x1b[0m %s/%s  %s", title, *namespace, strings.Join(tabs, "")))
+
+	// 过滤条件
+	count := 0
+	if t.table != nil {
+		count = len(t.table.rows)
+	}
+	filterLine := fmt.Sprintf("%d %s", count, res.name)
+	switch {
+	case t.filtering:
+		filterLine = fmt.Sprintf("filter: %s\x1b[7m \x1b[0m  (%d matches)", t.filter, count)
+	case t.filter != "":
+		filterLine = fmt.Sprintf("filter: %s  (%d matches, esc to clear)", t.filter, count)
+	}
+	if t.message != "" {
+		filterLine += "  \x1b[1;31m" + t.message + "\x1b[0m"
+	}
+	lines = append(lines, filterLine)
+
+	detailHeight := height / 3
+	if detailHeight > 12 {
+		detailHeight = 12
+	}
+	listHeight := height - len(lines) - 1 - detailHeight - 2
+	if listHeight < 1 {
+		listHeight = 1
+	}
+
+	// 列表, 不显示 Number 列
+	if t.table != nil {
+		headers := t.table.headers[1:]
+		widths := make([]int, len(headers))
+		for i, h := range headers {
+			widths[i] = utf8.RuneCountInString(h)
+		}
+		for _, row := range t.table.rows {
+			for i := range headers {
+				if w := utf8.RuneCountInString(row[i+1]); w > widths[i] {
+					widths[i] = w
+				}
+			}
+		}
+		format := func(cells []string) string {
+			var parts []string
+			for i := range headers {
+				parts = append(parts, padRight(cells[i], widths[i]))
+			}
+			return " " + strings.Join(parts, "  ")
+		}
+		lines = append(lines, "\x1b[1m"+truncate(format(upper(headers)), width)+"\x1b[0m")
+
+		if t.cursor < t.offset {
+			t.offset = t.cursor
+		}
+		if t.cursor >= t.offset+listHeight {
+			t.offset = t.cursor - listHeight + 1
+		}
+		for i := t.offset; i < t.offset+listHeight; i++ {
+			if i >= len(t.table.rows) {
+				lines = append(lines, "")
+				continue
+			}
+			text := truncate(format(t.table.rows[i][1:]), width)
+			if i == t.cursor {
+				text = "\x1b[7m" + padRight(text, width) + "\x1b[0m"
+			}
+			lines = append(lines, text)
+		}
+	} else {
+		lines = append(lines, "")
+		for i := 0; i < listHeight; i++ {
+			lines = append(lines, "")
+		}
+	}
+
+	// 详情
+	lines = append(lines, strings.Repeat("─", width))
+	var details []string
+	if obj := t.selected(); obj != nil {
+		details = res.details(obj)
+	}
+	for i := 0; i < detailHeight; i++ {
+		if i < len(details) {
+			lines = append(lines, truncate(" "+details[i], width))
+		} else {
+			lines = append(lines, "")
+		}
+	}
+
+	// 快捷键
+	footer := []string{"↑↓ move", "/ filter", "tab resource", "enter actions"}
+	for _, h := range res.hotkeys {
+		footer = append(footer, fmt.Sprintf("%c %s", h.key, h.label))
+	}
+	footer = append(footer, "N namespace", "q quit")
+	lines = append(lines, "\x1b[2m"+truncate(strings.Join(footer, "  "), width)+"\x1b[0m")
+
+	var buf bytes.Buffer
+	buf.WriteString("\x1b[H")
+	for i, l := range lines {
+		if i >= height {
+			break
+		}
+		buf.WriteString(l)
+		buf.WriteString("\x1b[K")
+		if i < len(lines)-1 && i < height-1 {
+			buf.WriteString("\r\n")
+		}
+	}
+	buf.WriteString("\x1b[J")
+	os.Stdout.Write(buf.Bytes())
+}
+
+func upper(s []string) []string {
+	out := make([]string, len(s))
+	for i, v := range s {
+		out[i] = strings.ToUpper(v)
+	}
+	return out
+}
+
+func padRight(s string, width int) string {
+	if n := utf8.RuneCountInString(s); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return s
+}
+
+// 按字符截断, 只用于不含颜色代码的文本
+func truncate(s string, width int) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:width])
+}
+
+type tuiKey struct {
+	name string // 特殊按键的名称, 普通字符为空
+	r    rune
+}
+
+// 解析原始模式下读到的按键, 包括方向键等转义序列
+func parseTUIKeys(b []byte) []tuiKey {
+	var keys []tuiKey
+	for i := 0; i < len(b); {
+		c := b[i]
+		switch {
+		case c == 0x1b:
+			if i+2 < len(b) && (b[i+1] == '[' || b[i+1] == 'O') {
+				seq := b[i+2]
+				names := map[byte]string{'A': "up", 'B': "down", 'C': "right", 'D': "left", 'H': "home", 'F': "end", 'Z': "backtab"}
+				if name, ok := names[seq]; ok {
+					keys = append(keys, tuiKey{name: name})
+					i += 3
+					continue
+				}
+				if seq >= '0' && seq <= '9' && i+3 < len(b) && b[i+3] == '~' {
+					// 不支持的按键 (例如 Insert) 直接忽略
+					names := map[byte]string{'1': "home", '7': "home", '4': "end", '8': "end", '5': "pgup", '6': "pgdn", '3': "delete"}
+					if name, ok := names[seq]; ok {
+						keys = append(keys, tuiKey{name: name})
+					}
+					i += 4
+					continue
+				}
+			}
+			keys = append(keys, tuiKey{name: "esc"})
+			i++
+		case c == 3:
+			keys = append(keys, tuiKey{name: "ctrl-c"})
+			i++
+		case c == '\r' || c == '\n':
+			keys = append(keys, tuiKey{name: "enter"})
+			i++
+		case c == '\t':
+			keys = append(keys, tuiKey{name: "tab"})
+			i++
+		case c == 127 || c == 8:
+			keys = append(keys, tuiKey{name: "backspace"})
+			i++
+		case c < 32:
+			i++
+		default:
+			r, size := utf8.DecodeRune(b[i:])
+			keys = append(keys, tuiKey{r: r})
+			i += size
+		}
+	}
+	return keys
+}
+
+func age(t time.Time) string {
+	if t.IsZero() {
+		return "<unknown>"
+	}
+	return duration.HumanDuration(time.Since(t))
+}
+
+func labelsString(labels map[string]string) string {
+	var parts []string
+	for k, v := range labels {
+		parts = append(parts, k+"="+v)
+	}
+	sort.Strings(parts)
+	if len(parts) == 0 {
+		return "<none>"
+	}
+	return strings.Join(parts, ",")
+}
+
+func podDetails(pod *v1.Pod) []string {
+	lines := []string{
+		fmt.Sprintf("Pod %s  phase %s  age %s", pod.Name, pod.Status.Phase, age(pod.CreationTimestamp.Time)),
+		fmt.Sprintf("Node: %s  IP: %s  QoS: %s", pod.Spec.NodeName, pod.Status.PodIP, pod.Status.QOSClass),
+		"Labels: " + labelsString(pod.Labels),
+	}
+	statuses := map[string]v1.ContainerStatus{}
+	for _, s := range pod.Status.ContainerStatuses {
+		statuses[s.Name] = s
+	}
+	for _, c := range pod.Spec.Containers {
+		s := statuses[c.Name]
+		state := "waiting"
+		switch {
+		case s.State.Running != nil:
+			state = "running since " + age(s.State.Running.StartedAt.Time)
+		case s.State.Terminated != nil:
+			state = fmt.Sprintf("terminated: %s (exit %d)", s.State.Terminated.Reason, s.State.Terminated.ExitCode)
+		case s.State.Waiting != nil:
+			state = "waiting: " + s.State.Waiting.Reason
+		}
+		lines = append(lines, fmt.Sprintf("  %s  %s  ready=%t restarts=%d  %s", c.Name, c.Image, s.Ready, s.RestartCount, state))
+	}
+	for _, cond := range pod.Status.Conditions {
+		if cond.Status != v1.ConditionTrue && cond.Message != "" {
+			lines = append(lines, fmt.Sprintf("%s: %s", cond.Type, cond.Message))
+		}
+	}
+	return lines
+}
+
+func deploymentDetails(d *appsv1.Deployment) []string {
+	desired := int32(1)
+	if d.Spec.Replicas != nil {
+		desired = *d.Spec.Replicas
+	}
+	lines := []string{
+		fmt.Sprintf("Deployment %s  age %s", d.Name, age(d.CreationTimestamp.Time)),
+		fmt.Sprintf("Replicas: %d desired, %d updated, %d ready, %d available", desired, d.Status.UpdatedReplicas, d.Status.ReadyReplicas, d.Status.AvailableReplicas),
+		fmt.Sprintf("Strategy: %s", d.Spec.Strategy.Type),
+		"Labels: " + labelsString(d.Labels),
+	}
+	if d.Spec.Selector != nil {
+		lines = append(lines, "Selector: "+labelsString(d.Spec.Selector.MatchLabels))
+	}
+	for _, c := range d.Spec.Template.Spec.Containers {
+		lines = append(lines, fmt.Sprintf("  %s  %s", c.Name, c.Image))
+	}
+	for _, cond := range d.Status.Conditions {
+		lines = append(lines, fmt.Sprintf("%s=%s %s", cond.Type, cond.Status, cond.Message))
+	}
+	return lines
+}
+
+func serviceDetails(svc *v1.Service) []string {
+	lines := []string{
+		fmt.Sprintf("Service %s  type %s  age %s", svc.Name, svc.Spec.Type, age(svc.CreationTimestamp.Time)),
+		fmt.Sprintf("ClusterIP: %s  ExternalIPs: %s", svc.Spec.ClusterIP, strings.Join(svc.Spec.ExternalIPs, ",")),
+		"Selector: " + labelsString(svc.Spec.Selector),
+		"Labels: " + labelsString(svc.Labels),
+	}
+	for _, p := range svc.Spec.Ports {
+		port := fmt.Sprintf("  %s %d -> %s/%s", p.Name, p.Port, p.TargetPort.String(), p.Protocol)
+		if p.NodePort != 0 {
+			port += fmt.Sprintf(" nodePort %d", p.NodePort)
+		}
+		lines = append(lines, port)
+	}
+	return lines
+}
+
+func configMapDetails(cm *v1.ConfigMap) []string {
+	lines := []string{
+		fmt.Sprintf("ConfigMap %s  size %s  age %s", cm.Name, formatBytes(configMapSize(*cm)), age(cm.CreationTimestamp.Time)),
+		"Labels: " + labelsString(cm.Labels),
+	}
+	for _, k := range configMapKeys(cm) {
+		keyType := "data"
+		if k.Binary {
+			keyType = "binaryData"
+		}
+		lines = append(lines, fmt.Sprintf("  %s  %s  %s", k.Name, keyType, formatBytes(k.Size)))
+	}
+	return lines
+}
+
+func pvcDetails(pvc *v1.PersistentVolumeClaim) []string {
+	var modes []string
+	for _, m := range pvc.Spec.AccessModes {
+		modes = append(modes, string(m))
+	}
+	storageClass := "<none>"
+	if pvc.Spec.StorageClassName != nil {
+		storageClass = *pvc.Spec.StorageClassName
+	}
+	return []string{
+		fmt.Sprintf("PersistentVolumeClaim %s  status %s  age %s", pvc.Name, pvc.Status.Phase, age(pvc.CreationTimestamp.Time)),
+		fmt.Sprintf("Volume: %s  Capacity: %s  StorageClass: %s", pvc.Spec.VolumeName, pvc.Status.Capacity.Storage().String(), storageClass),
+		"AccessModes: " + strings.Join(modes, ","),
+		"Labels: " + labelsString(pvc.Labels),
+	}
+}
+
+func pvDetails(pv *v1.PersistentVolume) []string {
+	claim := "<none>"
+	if pv.Spec.ClaimRef != nil {
+		claim = pv.Spec.ClaimRef.Namespace + "/" + pv.Spec.ClaimRef.Name
+	}
+	return []string{
+		fmt.Sprintf("PersistentVolume %s  status %s  age %s", pv.Name, pv.Status.Phase, age(pv.CreationTimestamp.Time)),
+		fmt.Sprintf("Capacity: %s  StorageClass: %s  ReclaimPolicy: %s", pv.Spec.Capacity.Storage().String(), pv.Spec.StorageClassName, pv.Spec.PersistentVolumeReclaimPolicy),
+		"Claim: " + claim,
+		"Labels: " + labelsString(pv.Labels),
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package main
+
+import "fmt"
+
+// 全屏模式需要轮询终端输入, 其他系统使用交互菜单
+func runTUI() {
+	fmt.Println("--tui is not supported on this platform, starting the interactive menu")
+	runMain()
+}
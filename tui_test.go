@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseTUIKeys(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []tuiKey
+	}{
+		{"j", []tuiKey{{r: 'j'}}},
+		{"日", []tuiKey{{r: '日'}}},
+		{"\x1b[A\x1b[B\x1bOC\x1b[D", []tuiKey{{name: "up"}, {name: "down"}, {name: "right"}, {name: "left"}}},
+		{"\x1b[H\x1b[F\x1b[1~\x1b[4~", []tuiKey{{name: "home"}, {name: "end"}, {name: "home"}, {name: "end"}}},
+		{"\x1b[5~\x1b[6~\x1b[3~", []tuiKey{{name: "pgup"}, {name: "pgdn"}, {name: "delete"}}},
+		{"\x1b[2~x", []tuiKey{{r: 'x'}}},
+		{"\x1b", []tuiKey{{name: "esc"}}},
+		{"\x1bq", []tuiKey{{name: "esc"}, {r: 'q'}}},
+		{"\r\n\t\x1b[Z", []tuiKey{{name: "enter"}, {name: "enter"}, {name: "tab"}, {name: "backtab"}}},
+		{"a\x7f\x08", []tuiKey{{r: 'a'}, {name: "backspace"}, {name: "backspace"}}},
+		{"\x03\x01", []tuiKey{{name: "ctrl-c"}}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := parseTUIKeys([]byte(tt.input)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseTUIKeys(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+// 加载中 Ctrl+C 只取消加载, 加载结束后再按才退出
+func TestTUICtrlCCancelsLoading(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ui := &tui{loading: cancel, loaded: make(chan tuiLoad, 1)}
+	if !ui.handleKey(tuiKey{name: "ctrl-c"}) {
+		t.Fatal("ctrl-c while loading quit the TUI")
+	}
+	if ctx.Err() == nil {
+		t.Fatal("ctrl-c did not cancel the load")
+	}
+	ui.finishLoad(tuiLoad{err: errors.New("loading pods: request interrupted")})
+	if ui.loading != nil || ui.message != "loading pods: request interrupted" {
+		t.Errorf("after the cancelled load: loading = %v, message = %q", ui.loading != nil, ui.message)
+	}
+	if ui.handleKey(tuiKey{name: "ctrl-c"}) {
+		t.Error("ctrl-c without a load in progress did not quit")
+	}
+}
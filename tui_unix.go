@@ -0,0 +1,81 @@
+//go:build unix
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+	"golang.org/x/term"
+)
+
+func runTUI() {
+	defer line.Close()
+	if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		fmt.Println("--tui requires a terminal")
+		return
+	}
+	if err := initKubeClient(); err != nil {
+		fmt.Println(err)
+		return
+	}
+	if *namespace == "" {
+		if err := selectNamespace(); err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+	t := &tui{
+		fd:        int(os.Stdin.Fd()),
+		resources: tuiResources(),
+		changed:   make(chan struct{}, 1),
+	}
+	if err := t.run(); err != nil {
+		fmt.Println(err)
+	}
+}
+
+func (t *tui) run() error {
+	if err := t.enter(); err != nil {
+		return err
+	}
+	defer t.leave()
+	defer t.unwatch()
+
+	resize := make(chan os.Signal, 1)
+	signal.Notify(resize, syscall.SIGWINCH)
+	defer signal.Stop(resize)
+
+	t.switchResource(0)
+	buf := make([]byte, 256)
+	for {
+		select {
+		case <-t.changed:
+			t.draw()
+		case <-resize:
+			t.draw()
+		case l := <-t.loaded:
+			t.finishLoad(l)
+			t.draw()
+		default:
+		}
+		// 轮询标准输入, 期间可以响应列表变化
+		fds := []unix.PollFd{{Fd: int32(t.fd), Events: unix.POLLIN}}
+		if n, err := unix.Poll(fds, 100); err != nil || n == 0 {
+			continue
+		}
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return err
+		}
+		for _, k := range parseTUIKeys(buf[:n]) {
+			if !t.handleKey(k) {
+				return nil
+			}
+		}
+		t.draw()
+	}
+}
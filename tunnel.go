@@ -0,0 +1,472 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+const (
+	// 默认的 tunnel 镜像
+	defaultTunnelImage = "alpine/socat"
+	// 默认的代理镜像, gost 在同一个端口上同时支持 SOCKS5 和 HTTP CONNECT
+	defaultProxyImage = "ginuerzh/gost:2.11.5"
+	// 代理在 pod 中监听的端口
+	proxyPort = 1080
+	// tunnel pod 默认使用的非 root 用户 (nobody)
+	defaultTunnelUser = 65534
+)
+
+// tunnel pod 的调度和准入相关配置, 对应 .kube-ui 中的 tunnelPod
+type TunnelPodConfig struct {
+	NodeSelector          map[string]string        `json:"nodeSelector,omitempty"`
+	Tolerations           []v1.Toleration          `json:"tolerations,omitempty"`
+	ServiceAccountName    string                   `json:"serviceAccountName,omitempty"`
+	PriorityClassName     string                   `json:"priorityClassName,omitempty"`
+	Labels                map[string]string        `json:"labels,omitempty"`
+	Annotations           map[string]string        `json:"annotations,omitempty"`
+	Resources             *v1.ResourceRequirements `json:"resources,omitempty"`             // 覆盖默认的 requests/limits
+	RunAsUser             *int64                   `json:"runAsUser,omitempty"`             // 覆盖默认的 65534
+	ActiveDeadlineSeconds *int64                   `json:"activeDeadlineSeconds,omitempty"` // 默认不限制运行时间, 失联的 tunnel 由 tunnel gc 按心跳清理
+}
+
+// 一条隧道映射: localhost:LocalPort -> tunnel pod:RemotePort -> Host:Port
+// UDP 映射在本地通过 ForwardPort 把报文封装到 TCP 中转发
+type tunnelMapping struct {
+	Protocol    string
+	LocalPort   int
+	Host        string
+	Port        int
+	RemotePort  int
+	ForwardPort int
+}
+
+func (m tunnelMapping) String() string {
+	if m.Protocol == "udp" {
+		return fmt.Sprintf("udp://localhost:%d -> udp://%s:%d", m.LocalPort, m.Host, m.Port)
+	}
+	return fmt.Sprintf("localhost:%d -> %s:%d", m.LocalPort, m.Host, m.Port)
+}
+
+func handleTunnelAction() {
+	for {
+		var mode string
+		options := []string{"forward targets", "socks5/http proxy", "through existing pod (exec)", "reverse tunnel (expose local port)", "exit"}
+		if len(currentConfig.Tunnels) > 0 {
+			options = append([]string{"saved tunnels"}, options...)
+		}
+		prompt := &survey.Select{
+			Message: "choose tunnel mode:",
+			Options: options,
+		}
+		if err := survey.AskOne(prompt, &mode); err != nil {
+			return
+		}
+		switch mode {
+		case "saved tunnels":
+			handleTunnelTemplateAction()
+		case "forward targets":
+			handleTunnelForwardAction()
+		case "socks5/http proxy":
+			handleTunnelProxyAction()
+		case "through existing pod (exec)":
+			handleTunnelExecAction()
+		case "reverse tunnel (expose local port)":
+			handleTunnelReverseAction()
+		default:
+			return
+		}
+	}
+}
+
+// 转发指定的 host:port 目标
+func handleTunnelForwardAction() {
+	for {
+		mappings := promptTunnelMappings("Enter targets as localPort=host:port separated by spaces (e.g. 5432=db.internal:5432 53=udp://kube-dns.kube-system:53), a single host:port, or 'exit' to quit: ")
+		if mappings == nil {
+			return
+		}
+		runTunnelSession(mappings)
+	}
+}
+
+// 询问隧道目标, 输入 exit 时返回 nil
+func promptTunnelMappings(message string) []tunnelMapping {
+	for {
+		var input string
+		prompt := &survey.Input{
+			Message: message,
+		}
+		survey.AskOne(prompt, &input)
+
+		input = strings.TrimSpace(input)
+		shouldReturn := checkExitCode(input)
+		if shouldReturn {
+			return nil
+		}
+
+		mappings, err := parseTunnelMappings(input)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+
+		// 只输入 host:port 时询问本地端口
+		if len(mappings) == 1 && mappings[0].LocalPort == 0 {
+			var localPort string
+			promptLocal := &survey.Input{
+				Message: "Enter local port to forward to: ",
+			}
+			survey.AskOne(promptLocal, &localPort)
+
+			localPort = strings.TrimSpace(localPort)
+			if localPort == "" {
+				fmt.Println("Local port is required")
+				continue
+			}
+			mappings[0].LocalPort, err = parsePort(localPort)
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+		}
+		return mappings
+	}
+}
+
+// 解析 "5432=db:5432 53=udp://dns:53", 没有本地端口时 LocalPort 为 0
+func parseTunnelMappings(input string) ([]tunnelMapping, error) {
+	var mappings []tunnelMapping
+	localPorts := map[string]bool{}
+	for _, field := range strings.Fields(input) {
+		m := tunnelMapping{Protocol: "tcp"}
+		target := field
+		if idx := strings.Index(field, "="); idx >= 0 {
+			port, err := parsePort(field[:idx])
+			if err != nil {
+				return nil, err
+			}
+			m.LocalPort = port
+			target = field[idx+1:]
+		}
+		switch {
+		case strings.HasPrefix(target, "udp://"):
+			m.Protocol = "udp"
+			target = strings.TrimPrefix(target, "udp://")
+		case strings.HasPrefix(target, "tcp://"):
+			target = strings.TrimPrefix(target, "tcp://")
+		}
+		idx := strings.LastIndex(target, ":")
+		if idx <= 0 {
+			return nil, fmt.Errorf("invalid target %q, please use localPort=host:port", field)
+		}
+		port, err := parsePort(target[idx+1:])
+		if err != nil {
+			return nil, err
+		}
+		m.Host, m.Port = target[:idx], port
+		if m.LocalPort != 0 {
+			key := fmt.Sprintf("%s/%d", m.Protocol, m.LocalPort)
+			if localPorts[key] {
+				return nil, fmt.Errorf("local port %d is used more than once", m.LocalPort)
+			}
+			localPorts[key] = true
+		}
+		mappings = append(mappings, m)
+	}
+	if len(mappings) == 0 {
+		return nil, fmt.Errorf("invalid format, please use localPort=host:port")
+	}
+	if len(mappings) > 1 {
+		for _, m := range mappings {
+			if m.LocalPort == 0 {
+				return nil, fmt.Errorf("local port is required for %s:%d when forwarding multiple targets", m.Host, m.Port)
+			}
+		}
+	}
+	assignRemotePorts(mappings)
+	return mappings, nil
+}
+
+func parsePort(s string) (int, error) {
+	port, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || port <= 0 || port > 65535 {
+		return 0, fmt.Errorf("invalid port %q", s)
+	}
+	return port, nil
+}
+
+// pod 内的监听端口默认与目标端口一致, 冲突时顺延
+// tunnel pod 以非 root 用户运行, 不能监听 1024 以下的端口
+func assignRemotePorts(mappings []tunnelMapping) {
+	used := map[int]bool{}
+	for i := range mappings {
+		port := mappings[i].Port
+		if port < 1024 {
+			port += 10000
+		}
+		for used[port] {
+			port++
+			if port > 65535 {
+				port = 1024
+			}
+		}
+		used[port] = true
+		mappings[i].RemotePort = port
+	}
+}
+
+// 当前配置中的 tunnel 镜像和镜像拉取密钥
+func tunnelImageConfig() (string, []v1.LocalObjectReference) {
+	tunnelImage := defaultTunnelImage
+	if currentConfig.TunnelImage != "" {
+		tunnelImage = currentConfig.TunnelImage
+	}
+	var imagePullSecrets []v1.LocalObjectReference
+	if currentConfig.ImagePullSecret != "" {
+		imagePullSecrets = append(imagePullSecrets, v1.LocalObjectReference{
+			Name: currentConfig.ImagePullSecret,
+		})
+	}
+	return tunnelImage, imagePullSecrets
+}
+
+// 所有目标共用一个 pod, 每个目标一个容器, TCP 使用 socat, UDP 使用中继脚本
+func buildTunnelPod(mappings []tunnelMapping) *v1.Pod {
+	tunnelImage, _ := tunnelImageConfig()
+	var containers []v1.Container
+	for i, m := range mappings {
+		if m.Protocol == "udp" {
+			containers = append(containers, udpRelayContainer(fmt.Sprintf("tunnel-%d", i), m))
+			continue
+		}
+		containers = append(containers, v1.Container{
+			Name:            fmt.Sprintf("tunnel-%d", i),
+			Image:           tunnelImage,
+			ImagePullPolicy: "IfNotPresent",
+			Command: []string{
+				"socat",
+				fmt.Sprintf("TCP-LISTEN:%d,fork,reuseaddr", m.RemotePort),
+				fmt.Sprintf("TCP:%s:%d", m.Host, m.Port),
+			},
+		})
+	}
+	return newTunnelPod("tunnel-pod", containers)
+}
+
+// 代理 pod, 只有一个 gost 容器
+func buildProxyPod() *v1.Pod {
+	proxyImage := defaultProxyImage
+	if currentConfig.ProxyImage != "" {
+		proxyImage = currentConfig.ProxyImage
+	}
+	return newTunnelPod("tunnel-proxy", []v1.Container{
+		{
+			Name:            "proxy",
+			Image:           proxyImage,
+			ImagePullPolicy: "IfNotPresent",
+			Command:         []string{"gost", fmt.Sprintf("-L=:%d", proxyPort)},
+		},
+	})
+}
+
+// tunnel 使用的 pod 的公共部分, 带有 gc 使用的标签和心跳注解
+func newTunnelPod(prefix string, containers []v1.Container) *v1.Pod {
+	_, imagePullSecrets := tunnelImageConfig()
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s", prefix, randomString(6)),
+			Namespace: *namespace,
+			Labels:    tunnelLabels(prefix, randomString(10)),
+			Annotations: map[string]string{
+				annotationHeartbeat: time.Now().UTC().Format(time.RFC3339),
+			},
+		},
+		Spec: v1.PodSpec{
+			ImagePullSecrets: imagePullSecrets,
+			Containers:       containers,
+			RestartPolicy:    v1.RestartPolicyNever,
+		},
+	}
+	applyTunnelPodProfile(pod, currentConfig.TunnelPod)
+	return pod
+}
+
+// 符合 Pod Security "restricted" 的安全配置和较小的默认资源, 再叠加配置中的覆盖项
+func applyTunnelPodProfile(pod *v1.Pod, cfg *TunnelPodConfig) {
+	if cfg == nil {
+		cfg = &TunnelPodConfig{}
+	}
+	runAsUser := int64(defaultTunnelUser)
+	if cfg.RunAsUser != nil {
+		runAsUser = *cfg.RunAsUser
+	}
+	resources := v1.ResourceRequirements{
+		Requests: v1.ResourceList{
+			v1.ResourceCPU:    resource.MustParse("10m"),
+			v1.ResourceMemory: resource.MustParse("32Mi"),
+		},
+		Limits: v1.ResourceList{
+			v1.ResourceCPU:    resource.MustParse("200m"),
+			v1.ResourceMemory: resource.MustParse("128Mi"),
+		},
+	}
+	if cfg.Resources != nil {
+		resources = *cfg.Resources
+	}
+
+	pod.Spec.AutomountServiceAccountToken = ptr.To(false)
+	pod.Spec.SecurityContext = &v1.PodSecurityContext{
+		RunAsNonRoot: ptr.To(true),
+		RunAsUser:    ptr.To(runAsUser),
+		RunAsGroup:   ptr.To(runAsUser),
+		SeccompProfile: &v1.SeccompProfile{
+			Type: v1.SeccompProfileTypeRuntimeDefault,
+		},
+	}
+	for i := range pod.Spec.Containers {
+		c := &pod.Spec.Containers[i]
+		c.SecurityContext = &v1.SecurityContext{
+			AllowPrivilegeEscalation: ptr.To(false),
+			ReadOnlyRootFilesystem:   ptr.To(true),
+			RunAsNonRoot:             ptr.To(true),
+			Capabilities: &v1.Capabilities{
+				Drop: []v1.Capability{"ALL"},
+			},
+		}
+		c.Resources = *resources.DeepCopy()
+	}
+
+	pod.Spec.NodeSelector = cfg.NodeSelector
+	pod.Spec.Tolerations = cfg.Tolerations
+	pod.Spec.ServiceAccountName = cfg.ServiceAccountName
+	pod.Spec.PriorityClassName = cfg.PriorityClassName
+	pod.Spec.ActiveDeadlineSeconds = cfg.ActiveDeadlineSeconds
+	// 额外的标签和注解不能覆盖 kube-ui 自己使用的
+	for k, v := range cfg.Labels {
+		if _, ok := pod.Labels[k]; !ok {
+			pod.Labels[k] = v
+		}
+	}
+	for k, v := range cfg.Annotations {
+		if _, ok := pod.Annotations[k]; !ok {
+			pod.Annotations[k] = v
+		}
+	}
+}
+
+// 部署代理 pod, 把一个本地端口作为 SOCKS5/HTTP CONNECT 代理
+func handleTunnelProxyAction() {
+	var localPort string
+	survey.AskOne(&survey.Input{
+		Message: "Enter local proxy port: ",
+		Default: fmt.Sprintf("%d", proxyPort),
+	}, &localPort)
+	port, err := parsePort(localPort)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	runProxyTunnel(port)
+}
+
+func runProxyTunnel(port int) {
+	fmt.Println("Creating proxy pod...")
+	pod, cleanup, err := startTunnelPod(buildProxyPod())
+	if err != nil {
+		fmt.Printf("Error creating proxy pod: %v\n", err)
+		return
+	}
+	defer cleanup()
+
+	if err := waitTunnelReady(pod, nil); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	fmt.Printf("SOCKS5 and HTTP CONNECT proxy listening on localhost:%d, for example:\n", port)
+	fmt.Printf("  curl -x socks5h://127.0.0.1:%d http://my-svc.my-namespace:8080\n", port)
+	fmt.Printf("  export HTTPS_PROXY=http://127.0.0.1:%d HTTP_PROXY=http://127.0.0.1:%d\n", port, port)
+	fmt.Println("Use socks5h:// so that DNS names are resolved inside the cluster.")
+	execCommand("port-forward", fmt.Sprintf("pod/%s", pod.Name), fmt.Sprintf("%d:%d", port, proxyPort))
+}
+
+// 创建 tunnel pod, 同时转发所有端口, 结束后删除 pod
+func runTunnelSession(mappings []tunnelMapping) {
+	tunnelPod := buildTunnelPod(mappings)
+
+	fmt.Printf("Creating tunnel pod for %d target(s)...\n", len(mappings))
+	pod, cleanup, err := startTunnelPod(tunnelPod)
+	if err != nil {
+		fmt.Printf("Error creating tunnel pod: %v\n", err)
+		return
+	}
+	defer cleanup()
+
+	if err := waitTunnelReady(pod, mappings); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	forwardPort := []string{"port-forward", fmt.Sprintf("pod/%s", pod.Name)}
+	for i := range mappings {
+		m := &mappings[i]
+		if m.Protocol == "udp" {
+			// port-forward 只支持 TCP, 本地启动 UDP 中继转发到一个空闲的 TCP 端口
+			m.ForwardPort, err = freeLocalPort()
+			if err != nil {
+				fmt.Printf("Error allocating local port: %v\n", err)
+				return
+			}
+			relay, err := startUDPRelay(m.LocalPort, m.ForwardPort)
+			if err != nil {
+				fmt.Printf("Error listening on udp port %d: %v\n", m.LocalPort, err)
+				return
+			}
+			defer relay.Close()
+			forwardPort = append(forwardPort, fmt.Sprintf("%d:%d", m.ForwardPort, m.RemotePort))
+		} else {
+			forwardPort = append(forwardPort, fmt.Sprintf("%d:%d", m.LocalPort, m.RemotePort))
+		}
+		fmt.Printf("Tunneling %s\n", m)
+	}
+	execCommand(forwardPort...)
+}
+
+func createTunnelPod(tunnelPod *v1.Pod) (*v1.Pod, error) {
+	ctx, cancel := requestContext()
+	defer cancel()
+	pod, err := k8sClient.CoreV1().Pods(*namespace).Create(ctx, tunnelPod, metav1.CreateOptions{})
+	auditAPI("tunnel", "create", "pod/"+tunnelPod.Name, err)
+	return pod, err
+}
+
+func deleteTunnelPod(name string) {
+	fmt.Println("Cleaning up tunnel pod...")
+	// 清理在 Ctrl+C 之后执行, 只使用超时
+	ctx, cancel := timeoutContext()
+	defer cancel()
+	err := k8sClient.CoreV1().Pods(*namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	auditAPI("tunnel", "delete", "pod/"+name, err)
+	if err != nil {
+		fmt.Printf("Error deleting tunnel pod: %v\n", err)
+	}
+}
+
+// 生成随机字符串
+func randomString(n int) string {
+	const letterBytes = "abcdefghijklmnopqrstuvwxyz0123456789"
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = letterBytes[rand.Intn(len(letterBytes))]
+	}
+	return string(b)
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// 按优先级排列的中继工具, bash 需要 /dev/tcp 和 cat
+var execRelayTools = []string{"socat", "nc", "ncat", "bash"}
+
+// 在 pod 的容器中执行命令, stdin 为 nil 时不打开标准输入
+func execInPod(ctx context.Context, ns, pod, container string, command []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	req := k8sClient.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(ns).
+		Name(pod).
+		SubResource("exec").
+		VersionedParams(&v1.PodExecOptions{
+			Container: container,
+			Command:   command,
+			Stdin:     stdin != nil,
+			Stdout:    stdout != nil,
+			Stderr:    stderr != nil,
+		}, scheme.ParameterCodec)
+	executor, err := remotecommand.NewSPDYExecutor(restConfig, "POST", req.URL())
+	if err != nil {
+		return err
+	}
+	return executor.StreamWithContext(ctx, remotecommand.StreamOptions{
+		Stdin:  stdin,
+		Stdout: stdout,
+		Stderr: stderr,
+	})
+}
+
+// 检测容器中可用的中继工具
+func detectRelayTools(ns, pod, container string) ([]string, error) {
+	script := `for t in socat nc ncat bash cat; do command -v "$t" >/dev/null 2>&1 && echo "$t"; done; true`
+	var stdout, stderr bytes.Buffer
+	ctx, cancel := requestContext()
+	defer cancel()
+	err := execInPod(ctx, ns, pod, container, []string{"sh", "-c", script}, nil, &stdout, &stderr)
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%v: %s", err, msg)
+		}
+		return nil, fmt.Errorf("can not run sh in container %s, the image may not have a shell: %v", container, err)
+	}
+	found := map[string]bool{}
+	for _, l := range strings.Fields(stdout.String()) {
+		found[l] = true
+	}
+	var tools []string
+	for _, t := range execRelayTools {
+		if !found[t] || (t == "bash" && !found["cat"]) {
+			continue
+		}
+		tools = append(tools, t)
+	}
+	return tools, nil
+}
+
+// 中继工具连接目标的命令, 标准输入输出即为连接的两端
+func relayCommand(tool, host string, port int) []string {
+	p := strconv.Itoa(port)
+	switch tool {
+	case "socat":
+		// 客户端关闭写方向后 socat 默认只再等待 0.5 秒, 这里一直等到目标关闭连接
+		return []string{"socat", "-t", "86400", "-", "TCP:" + net.JoinHostPort(host, p)}
+	case "bash":
+		// 非交互 shell 的后台命令标准输入默认是 /dev/null, 需要显式重定向
+		return []string{"bash", "-c", `exec 3<>"/dev/tcp/$0/$1" || exit 1; cat <&0 >&3 & cat <&3; kill $! 2>/dev/null; exit 0`, host, p}
+	default:
+		return []string{tool, host, p}
+	}
+}
+
+// 选择一个运行中的 pod 和容器
+func selectRunningContainer() (string, string, bool) {
+	ctx, cancel := requestContext()
+	defer cancel()
+	pods, err := k8sClient.CoreV1().Pods(*namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: "status.phase=Running",
+	})
+	err = requestError(ctx, err)
+	if err != nil {
+		fmt.Printf("Error listing pods: %v\n", err)
+		return "", "", false
+	}
+	if len(pods.Items) == 0 {
+		fmt.Printf("No running pods in namespace %s\n", *namespace)
+		return "", "", false
+	}
+	var podNames []string
+	for _, pod := range pods.Items {
+		podNames = append(podNames, pod.Name)
+	}
+	var podIndex int
+	if err := survey.AskOne(&survey.Select{
+		Message: "choose pod to tunnel through:",
+		Options: podNames,
+	}, &podIndex); err != nil {
+		return "", "", false
+	}
+	pod := pods.Items[podIndex]
+
+	var containers []string
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.State.Running != nil {
+			containers = append(containers, status.Name)
+		}
+	}
+	if len(containers) == 0 {
+		fmt.Printf("No running containers in pod %s\n", pod.Name)
+		return "", "", false
+	}
+	container := containers[0]
+	if len(containers) > 1 {
+		if err := survey.AskOne(&survey.Select{
+			Message: "choose container:",
+			Options: containers,
+		}, &container); err != nil {
+			return "", "", false
+		}
+	}
+	return pod.Name, container, true
+}
+
+// 不创建 pod, 通过 exec 在已有 pod 中运行 socat/nc/bash 转发连接
+func handleTunnelExecAction() {
+	podName, container, ok := selectRunningContainer()
+	if !ok {
+		return
+	}
+
+	fmt.Printf("Detecting relay tools in %s/%s...\n", podName, container)
+	tools, err := detectRelayTools(*namespace, podName, container)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if len(tools) == 0 {
+		fmt.Printf("None of %s found in container %s, please choose another pod\n", strings.Join(execRelayTools, ", "), container)
+		return
+	}
+	tool := tools[0]
+	if len(tools) > 1 {
+		if err := survey.AskOne(&survey.Select{
+			Message: "choose relay tool:",
+			Options: tools,
+			Default: tool,
+		}, &tool); err != nil {
+			return
+		}
+	}
+
+	mappings := promptTunnelMappings("Enter targets as localPort=host:port separated by spaces (e.g. 5432=db.internal:5432), a single host:port, or 'exit' to quit: ")
+	if mappings == nil {
+		return
+	}
+	for _, m := range mappings {
+		if m.Protocol == "udp" {
+			fmt.Println("UDP targets are not supported when tunneling through an existing pod")
+			return
+		}
+	}
+	runExecTunnel(podName, container, tool, mappings)
+}
+
+// 在本地监听端口, 每个连接通过一次 exec 转发, Ctrl+C 结束
+func runExecTunnel(podName, container, tool string, mappings []tunnelMapping) {
+	ns := *namespace
+	var listeners []net.Listener
+	defer func() {
+		for _, l := range listeners {
+			l.Close()
+		}
+	}()
+	for _, m := range mappings {
+		l, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", m.LocalPort))
+		if err != nil {
+			fmt.Printf("Error listening on port %d: %v\n", m.LocalPort, err)
+			return
+		}
+		listeners = append(listeners, l)
+		go serveExecTunnel(l, ns, podName, container, relayCommand(tool, m.Host, m.Port))
+		fmt.Printf("Tunneling %s via pod/%s (%s)\n", m, podName, tool)
+	}
+	auditAPI("tunnel-exec", "exec", "pod/"+podName, nil)
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt)
+	defer signal.Stop(sigChan)
+	fmt.Println("Press Ctrl+C to stop")
+	<-sigChan
+	fmt.Println()
+}
+
+func serveExecTunnel(l net.Listener, ns, podName, container string, command []string) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			// 客户端只关闭写方向 (shutdown(SHUT_WR)) 时读到 EOF, exec 会关闭远端的标准输入并继续转发响应
+			// 读取出错说明连接已断开, 直接结束 exec
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			var stderr bytes.Buffer
+			err := execInPod(ctx, ns, podName, container, command, &cancelOnReadError{r: conn, cancel: cancel}, conn, &stderr)
+			if err != nil && ctx.Err() == nil {
+				fmt.Printf("Tunnel connection from %s failed: %v %s\n", conn.RemoteAddr(), err, strings.TrimSpace(stderr.String()))
+			}
+		}()
+	}
+}
+
+// 读取出错时取消 exec, EOF 不取消
+type cancelOnReadError struct {
+	r      io.Reader
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnReadError) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	if err != nil && err != io.EOF {
+		c.cancel()
+	}
+	return n, err
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os/exec"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRelayCommand(t *testing.T) {
+	tests := []struct {
+		tool string
+		host string
+		port int
+		want []string
+	}{
+		{"socat", "db", 5432, []string{"socat", "-t", "86400", "-", "TCP:db:5432"}},
+		{"socat", "fd00::1", 80, []string{"socat", "-t", "86400", "-", "TCP:[fd00::1]:80"}},
+		{"nc", "db", 5432, []string{"nc", "db", "5432"}},
+		{"ncat", "db", 5432, []string{"ncat", "db", "5432"}},
+	}
+	for _, tt := range tests {
+		if got := relayCommand(tt.tool, tt.host, tt.port); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("relayCommand(%q, %q, %d) = %v, want %v", tt.tool, tt.host, tt.port, got, tt.want)
+		}
+	}
+}
+
+// 在本地运行中继命令: 客户端写完请求并关闭写方向后, 仍然要收到目标的完整响应
+// bash 的 /dev/tcp 不能只关闭写方向, 目标按行读取请求
+func TestRelayCommandHalfClose(t *testing.T) {
+	target, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer target.Close()
+	go func() {
+		for {
+			c, err := target.Accept()
+			if err != nil {
+				return
+			}
+			go func(c net.Conn) {
+				defer c.Close()
+				request, _ := bufio.NewReader(c).ReadString('\n')
+				// 读到请求后稍等再响应, 超过 socat 默认的 0.5 秒
+				time.Sleep(700 * time.Millisecond)
+				c.Write([]byte(strings.ToUpper(request)))
+			}(c)
+		}
+	}()
+	port := target.Addr().(*net.TCPAddr).Port
+
+	for _, tool := range []string{"socat", "bash"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			t.Logf("%s not installed, skipped", tool)
+			continue
+		}
+		command := relayCommand(tool, "127.0.0.1", port)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+		cmd.WaitDelay = time.Second
+		cmd.Stdin = strings.NewReader("ping " + strconv.Itoa(port) + "\n")
+		out, err := cmd.Output()
+		cancel()
+		if err != nil {
+			t.Errorf("%s relay failed: %v", tool, err)
+			continue
+		}
+		if want := "PING " + strconv.Itoa(port) + "\n"; string(out) != want {
+			t.Errorf("%s relay response = %q, want %q", tool, out, want)
+		}
+	}
+}
+
+func TestCancelOnReadError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantCancel bool
+	}{
+		{"eof", io.EOF, false},
+		{"reset", errors.New("connection reset by peer"), true},
+	}
+	for _, tt := range tests {
+		cancelled := false
+		r := &cancelOnReadError{r: io.MultiReader(strings.NewReader("data"), &errorReader{tt.err}), cancel: func() { cancelled = true }}
+		data, err := io.ReadAll(r)
+		if string(data) != "data" {
+			t.Errorf("%s: read %q, want data", tt.name, data)
+		}
+		if tt.err != io.EOF && err != tt.err {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, tt.err)
+		}
+		if cancelled != tt.wantCancel {
+			t.Errorf("%s: cancelled = %v, want %v", tt.name, cancelled, tt.wantCancel)
+		}
+	}
+}
+
+type errorReader struct{ err error }
+
+func (r *errorReader) Read(p []byte) (int, error) { return 0, r.err }
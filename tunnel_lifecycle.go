@@ -0,0 +1,326 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"regexp"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// tunnel pod 的标签和注解
+const (
+	labelManagedBy       = "app.kubernetes.io/managed-by"
+	labelComponent       = "kube-ui/component"
+	labelOwner           = "kube-ui/owner"
+	labelHost            = "kube-ui/host"
+	labelSession         = "kube-ui/session"
+	annotationHeartbeat  = "kube-ui/heartbeat"
+	tunnelHeartbeatEvery = 30 * time.Second
+)
+
+var (
+	gcAllNamespaces *bool
+	gcStale         *time.Duration
+	gcYes           *bool
+)
+
+var tunnelCmd = &cobra.Command{
+	Use:   "tunnel [name...]",
+	Short: "Start saved tunnels or manage kube-ui tunnels",
+	Long: `Start the tunnels saved under "tunnels" in ~/.kube-ui by name, several names share one tunnel pod. Without a name the saved tunnels are listed.
+
+"kube-ui tunnel <name>" is a shortcut for "kube-ui tunnel run <name>", tunnels named like a subcommand (e.g. gc or start) can only be started with run`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runTunnelCommand(args)
+	},
+}
+
+var tunnelRunCmd = &cobra.Command{
+	Use:   "run name...",
+	Short: "Start saved tunnels by name",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runTunnelCommand(args)
+	},
+}
+
+var tunnelGCCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Find and delete orphaned tunnel pods and services",
+	Long:  `Find tunnel pods whose kube-ui session is gone (no heartbeat for --stale, or already terminated) and reverse tunnel services without a live pod, and delete them`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := initKubeClient(); err != nil {
+			fmt.Println(err)
+			return
+		}
+		runTunnelGC(*gcAllNamespaces || *namespace == "", *gcStale, *gcYes)
+	},
+}
+
+func init() {
+	gcAllNamespaces = tunnelGCCmd.Flags().BoolP("all-namespaces", "A", false, "look for orphaned tunnel pods in all namespaces")
+	gcStale = tunnelGCCmd.Flags().Duration("stale", 3*time.Minute, "treat tunnels without a heartbeat for this long as orphaned")
+	gcYes = tunnelGCCmd.Flags().BoolP("yes", "y", false, "delete without confirmation")
+
+	tunnelCmd.AddCommand(tunnelGCCmd, tunnelRunCmd)
+	tunnelSubcommands = tunnelCmd.Commands
+	rootCmd.AddCommand(tunnelCmd)
+}
+
+// 收到退出信号时需要执行的清理函数
+var (
+	cleanupMu      sync.Mutex
+	cleanupFuncs   = map[int]func(){}
+	cleanupNextID  int
+	cleanupSignals chan os.Signal
+	cleanupStop    chan struct{}
+)
+
+// 注册清理函数, 返回取消注册的函数
+// 注册第一个时开始处理退出信号, 最后一个取消注册后恢复信号的默认处理
+func registerCleanup(fn func()) func() {
+	cleanupMu.Lock()
+	defer cleanupMu.Unlock()
+	if len(cleanupFuncs) == 0 {
+		notifyCleanupSignals()
+	}
+	id := cleanupNextID
+	cleanupNextID++
+	cleanupFuncs[id] = fn
+	return func() {
+		cleanupMu.Lock()
+		defer cleanupMu.Unlock()
+		if _, ok := cleanupFuncs[id]; !ok {
+			return
+		}
+		delete(cleanupFuncs, id)
+		if len(cleanupFuncs) == 0 {
+			signal.Stop(cleanupSignals)
+			close(cleanupStop)
+			cleanupSignals, cleanupStop = nil, nil
+		}
+	}
+}
+
+// 终端关闭或进程被终止时清理 tunnel pod, 调用时持有 cleanupMu
+func notifyCleanupSignals() {
+	sigChan := make(chan os.Signal, 1)
+	stop := make(chan struct{})
+	signal.Notify(sigChan, syscall.SIGHUP, syscall.SIGTERM, syscall.SIGQUIT)
+	cleanupSignals, cleanupStop = sigChan, stop
+	go func() {
+		select {
+		case sig := <-sigChan:
+			fmt.Printf("\nReceived signal: %v, cleaning up...\n", sig)
+			runCleanups()
+			line.Close()
+			os.Exit(1)
+		case <-stop:
+		}
+	}()
+}
+
+func runCleanups() {
+	cleanupMu.Lock()
+	funcs := make([]func(), 0, len(cleanupFuncs))
+	for _, fn := range cleanupFuncs {
+		funcs = append(funcs, fn)
+	}
+	cleanupMu.Unlock()
+	for _, fn := range funcs {
+		fn()
+	}
+}
+
+var invalidLabelChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// 转换为合法的标签值
+func sanitizeLabelValue(s string) string {
+	s = invalidLabelChars.ReplaceAllString(s, "-")
+	if len(s) > 63 {
+		s = s[:63]
+	}
+	return strings.Trim(s, "-_.")
+}
+
+// tunnel pod 的标签, 用于 gc 找到 kube-ui 创建的 pod
+func tunnelLabels(component, session string) map[string]string {
+	hostname, _ := os.Hostname()
+	return map[string]string{
+		labelManagedBy: "kube-ui",
+		labelComponent: component,
+		labelOwner:     sanitizeLabelValue(currentOSUser()),
+		labelHost:      sanitizeLabelValue(hostname),
+		labelSession:   session,
+	}
+}
+
+// 创建 tunnel pod 并登记清理: 正常返回, panic 和退出信号都会删除 pod
+// 返回的 cleanup 可以重复调用
+func startTunnelPod(tunnelPod *v1.Pod) (*v1.Pod, func(), error) {
+	pod, err := createTunnelPod(tunnelPod)
+	if err != nil {
+		return nil, func() {}, err
+	}
+
+	stopHeartbeat := make(chan struct{})
+	var once sync.Once
+	var unregister func()
+	cleanup := func() {
+		once.Do(func() {
+			close(stopHeartbeat)
+			unregister()
+			deleteTunnelPod(pod.Name)
+		})
+	}
+	unregister = registerCleanup(cleanup)
+	go tunnelHeartbeat(pod.Namespace, pod.Name, stopHeartbeat)
+	return pod, cleanup, nil
+}
+
+// 定期更新心跳注解, gc 据此区分存活和失联的 tunnel
+// 只输出第一次失败和恢复, 避免刷屏
+func tunnelHeartbeat(ns, name string, stop chan struct{}) {
+	ticker := time.NewTicker(tunnelHeartbeatEvery)
+	defer ticker.Stop()
+	failing := false
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			patch := fmt.Sprintf(`{"metadata":{"annotations":{%q:%q}}}`, annotationHeartbeat, time.Now().UTC().Format(time.RFC3339))
+			ctx, cancel := timeoutContext()
+			_, err := k8sClient.CoreV1().Pods(ns).Patch(ctx, name, types.MergePatchType, []byte(patch), metav1.PatchOptions{})
+			err = requestError(ctx, err)
+			cancel()
+			switch {
+			case err != nil && !failing:
+				fmt.Printf("\033[1;33mError updating heartbeat of tunnel pod %s, tunnel gc may treat it as orphaned: %v\033[0m\n", name, err)
+				failing = true
+			case err == nil && failing:
+				fmt.Printf("Heartbeat of tunnel pod %s updated again\n", name)
+				failing = false
+			}
+		}
+	}
+}
+
+// 判断 tunnel pod 是否已经没有 kube-ui 会话在使用
+func isOrphanedTunnelPod(pod v1.Pod, stale time.Duration) (bool, string) {
+	if pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+		return true, string(pod.Status.Phase)
+	}
+	last := pod.CreationTimestamp.Time
+	if hb, err := time.Parse(time.RFC3339, pod.Annotations[annotationHeartbeat]); err == nil {
+		last = hb
+	}
+	if time.Since(last) > stale {
+		return true, fmt.Sprintf("no heartbeat for %s", time.Since(last).Round(time.Second))
+	}
+	return false, ""
+}
+
+func runTunnelGC(allNamespaces bool, stale time.Duration, yes bool) {
+	ns := *namespace
+	if allNamespaces {
+		ns = metav1.NamespaceAll
+	}
+	ctx, cancel := requestContext()
+	defer cancel()
+	pods, err := k8sClient.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{
+		LabelSelector: labelManagedBy + "=kube-ui," + labelComponent,
+	})
+	if err != nil {
+		fmt.Printf("Error listing tunnel pods: %v\n", requestError(ctx, err))
+		return
+	}
+
+	// 反向隧道的 Service, 对应的 pod 不存在或已失联时一起删除
+	services, err := k8sClient.CoreV1().Services(ns).List(ctx, metav1.ListOptions{
+		LabelSelector: labelManagedBy + "=kube-ui," + labelComponent,
+	})
+	if err != nil {
+		fmt.Printf("Error listing tunnel services: %v\n", requestError(ctx, err))
+		return
+	}
+
+	type tunnelObject struct {
+		kind string
+		meta metav1.ObjectMeta
+	}
+	var orphans []tunnelObject
+	aliveSessions := map[string]bool{}
+	table := newOutputTable("Namespace", "Kind", "Name", "Component", "Owner", "Host", "Age", "State")
+	appendRow := func(kind string, meta metav1.ObjectMeta, orphaned bool, reason string) {
+		state := "alive"
+		if orphaned {
+			state = "orphaned: " + reason
+			orphans = append(orphans, tunnelObject{kind: kind, meta: meta})
+		}
+		table.append(nil,
+			meta.Namespace,
+			kind,
+			meta.Name,
+			meta.Labels[labelComponent],
+			meta.Labels[labelOwner],
+			meta.Labels[labelHost],
+			time.Since(meta.CreationTimestamp.Time).Round(time.Second).String(),
+			state,
+		)
+	}
+	for _, pod := range pods.Items {
+		orphaned, reason := isOrphanedTunnelPod(pod, stale)
+		if !orphaned {
+			aliveSessions[pod.Namespace+"/"+pod.Labels[labelSession]] = true
+		}
+		appendRow("pod", pod.ObjectMeta, orphaned, reason)
+	}
+	for _, svc := range services.Items {
+		alive := aliveSessions[svc.Namespace+"/"+svc.Labels[labelSession]]
+		appendRow("service", svc.ObjectMeta, !alive, "no live tunnel pod")
+	}
+	table.render()
+
+	if len(orphans) == 0 {
+		fmt.Println("No orphaned tunnel objects found")
+		return
+	}
+	if !yes {
+		confirm := false
+		survey.AskOne(&survey.Confirm{
+			Message: fmt.Sprintf("Delete %d orphaned tunnel object(s)?", len(orphans)),
+			Default: false,
+		}, &confirm)
+		if !confirm {
+			return
+		}
+	}
+	for _, obj := range orphans {
+		var err error
+		ctx, cancel := requestContext()
+		if obj.kind == "service" {
+			err = k8sClient.CoreV1().Services(obj.meta.Namespace).Delete(ctx, obj.meta.Name, metav1.DeleteOptions{})
+		} else {
+			err = k8sClient.CoreV1().Pods(obj.meta.Namespace).Delete(ctx, obj.meta.Name, metav1.DeleteOptions{})
+		}
+		err = requestError(ctx, err)
+		cancel()
+		auditAPINamespace(obj.meta.Namespace, "tunnel-gc", "delete", obj.kind+"/"+obj.meta.Name, err)
+		if err != nil {
+			fmt.Printf("Error deleting %s %s/%s: %v\n", obj.kind, obj.meta.Namespace, obj.meta.Name, err)
+			continue
+		}
+		fmt.Printf("Deleted %s %s/%s\n", obj.kind, obj.meta.Namespace, obj.meta.Name)
+	}
+}
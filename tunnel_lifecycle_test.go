@@ -0,0 +1,79 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+func TestIsOrphanedTunnelPod(t *testing.T) {
+	now := time.Now()
+	pod := func(phase v1.PodPhase, created time.Duration, heartbeat string) v1.Pod {
+		p := v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(now.Add(-created))},
+			Status:     v1.PodStatus{Phase: phase},
+		}
+		if heartbeat != "" {
+			p.Annotations = map[string]string{annotationHeartbeat: heartbeat}
+		}
+		return p
+	}
+	recent := now.Add(-time.Minute).UTC().Format(time.RFC3339)
+	old := now.Add(-time.Hour).UTC().Format(time.RFC3339)
+	tests := []struct {
+		name       string
+		pod        v1.Pod
+		want       bool
+		wantReason string
+	}{
+		{"failed", pod(v1.PodFailed, time.Minute, recent), true, "Failed"},
+		{"succeeded", pod(v1.PodSucceeded, time.Minute, recent), true, "Succeeded"},
+		{"recent heartbeat", pod(v1.PodRunning, 2*time.Hour, recent), false, ""},
+		{"stale heartbeat", pod(v1.PodRunning, 2*time.Hour, old), true, "no heartbeat for 1h"},
+		{"new pod without heartbeat", pod(v1.PodPending, time.Minute, ""), false, ""},
+		{"old pod without heartbeat", pod(v1.PodRunning, 2*time.Hour, ""), true, "no heartbeat for 2h"},
+		{"invalid heartbeat", pod(v1.PodRunning, 2*time.Hour, "yesterday"), true, "no heartbeat for 2h"},
+	}
+	for _, tt := range tests {
+		got, reason := isOrphanedTunnelPod(tt.pod, 10*time.Minute)
+		if got != tt.want || !strings.HasPrefix(reason, tt.wantReason) {
+			t.Errorf("%s: isOrphanedTunnelPod() = %v, %q, want %v, %q", tt.name, got, reason, tt.want, tt.wantReason)
+		}
+	}
+}
+
+func TestRegisterCleanup(t *testing.T) {
+	var calls []string
+	unregisterA := registerCleanup(func() { calls = append(calls, "a") })
+	unregisterB := registerCleanup(func() { calls = append(calls, "b") })
+	unregisterA()
+	unregisterA()
+	runCleanups()
+	if cleanupSignals == nil {
+		t.Error("signals are not handled while a cleanup is registered")
+	}
+	unregisterB()
+	runCleanups()
+	if len(calls) != 1 || calls[0] != "b" {
+		t.Errorf("cleanups called = %v, want [b]", calls)
+	}
+	if cleanupSignals != nil {
+		t.Error("signals are still handled after the last cleanup was unregistered")
+	}
+}
+
+func TestTunnelLabels(t *testing.T) {
+	labels := tunnelLabels("tunnel-pod", "abc123")
+	if labels[labelManagedBy] != "kube-ui" || labels[labelComponent] != "tunnel-pod" || labels[labelSession] != "abc123" {
+		t.Errorf("tunnelLabels() = %v", labels)
+	}
+	for k, v := range labels {
+		if errs := validation.IsValidLabelValue(v); len(errs) > 0 {
+			t.Errorf("label %s=%q is not valid: %v", k, v, errs)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+	utilexec "k8s.io/client-go/util/exec"
+)
+
+// tunnel pod 就绪和连通性检查的默认超时时间, 可以通过 tunnelTimeoutSeconds 配置
+const defaultTunnelTimeout = 2 * time.Minute
+
+// 连通性检查中单次连接的超时时间
+const tunnelProbeTimeout = 5
+
+func tunnelTimeout() time.Duration {
+	if currentConfig.TunnelTimeoutSeconds > 0 {
+		return time.Duration(currentConfig.TunnelTimeoutSeconds) * time.Second
+	}
+	return defaultTunnelTimeout
+}
+
+// 等待 tunnel pod 就绪并检查目标是否可达, 超时或 Ctrl+C 时返回错误
+func waitTunnelReady(pod *v1.Pod, mappings []tunnelMapping) error {
+	timeout := tunnelTimeout()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// 等待期间 Ctrl+C 取消等待, 由调用方删除 pod
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt)
+	defer signal.Stop(sigChan)
+	go func() {
+		select {
+		case <-sigChan:
+			fmt.Println("\nInterrupted")
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	err := waitTunnelPodRunning(ctx, pod.Name)
+	if err == nil && len(mappings) > 0 {
+		err = probeTunnelTargets(ctx, pod.Name, mappings)
+	}
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return fmt.Errorf("tunnel pod %s is not ready after %s, the timeout can be changed with tunnelTimeoutSeconds in ~/.kube-ui", pod.Name, timeout)
+	case errors.Is(ctx.Err(), context.Canceled):
+		return fmt.Errorf("tunnel cancelled")
+	}
+	return err
+}
+
+// 监听 pod 状态直到所有容器都在运行, 只输出新的状态和事件
+func waitTunnelPodRunning(ctx context.Context, name string) error {
+	fmt.Println("Waiting for tunnel pod to be ready...")
+	startTime := time.Now()
+	go printNewPodEvents(ctx, name)
+
+	lw := cache.NewListWatchFromClient(k8sClient.CoreV1().RESTClient(), "pods", *namespace, fields.OneTermEqualSelector("metadata.name", name))
+	lastState := ""
+	_, err := watchtools.UntilWithSync(ctx, lw, &v1.Pod{}, nil, func(event watch.Event) (bool, error) {
+		if event.Type == watch.Deleted {
+			return false, fmt.Errorf("tunnel pod %s was deleted", name)
+		}
+		pod, ok := event.Object.(*v1.Pod)
+		if !ok {
+			return false, nil
+		}
+		if state := tunnelPodState(pod); state != lastState {
+			fmt.Printf("[%s] %s\n", time.Since(startTime).Round(time.Second), state)
+			lastState = state
+		}
+		switch pod.Status.Phase {
+		case v1.PodFailed, v1.PodSucceeded:
+			return false, fmt.Errorf("tunnel pod %s exited: %s", name, lastState)
+		case v1.PodRunning:
+			return allContainersRunning(pod), nil
+		}
+		return false, nil
+	})
+	return err
+}
+
+// pod 阶段和未运行容器的原因
+func tunnelPodState(pod *v1.Pod) string {
+	parts := []string{"Pod status: " + string(pod.Status.Phase)}
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.State.Waiting != nil {
+			parts = append(parts, fmt.Sprintf("container %s is waiting: %s %s",
+				status.Name, status.State.Waiting.Reason, status.State.Waiting.Message))
+		}
+		if status.State.Terminated != nil {
+			parts = append(parts, fmt.Sprintf("container %s terminated: %s %s (exit code: %d)",
+				status.Name, status.State.Terminated.Reason, status.State.Terminated.Message, status.State.Terminated.ExitCode))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func allContainersRunning(pod *v1.Pod) bool {
+	if len(pod.Status.ContainerStatuses) < len(pod.Spec.Containers) {
+		return false
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.State.Running == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// 输出 pod 的事件, 相同的事件只输出一次
+func printNewPodEvents(ctx context.Context, name string) {
+	w, err := k8sClient.CoreV1().Events(*namespace).Watch(ctx, metav1.ListOptions{
+		FieldSelector: fmt.Sprintf("involvedObject.name=%s,involvedObject.kind=Pod", name),
+	})
+	if err != nil {
+		return
+	}
+	defer w.Stop()
+	seen := map[string]bool{}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e, ok := <-w.ResultChan():
+			if !ok {
+				return
+			}
+			event, ok := e.Object.(*v1.Event)
+			if !ok {
+				continue
+			}
+			key := event.Reason + "/" + event.Message
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			fmt.Printf("Event: Type=%s Reason=%s Message=%s\n", event.Type, event.Reason, event.Message)
+		}
+	}
+}
+
+// 在每个目标对应的容器中发起一次连接, 确认目标在集群内可达
+func probeTunnelTargets(ctx context.Context, podName string, mappings []tunnelMapping) error {
+	fmt.Println("Testing connections from the tunnel pod...")
+	var failed []string
+	for i, m := range mappings {
+		container := fmt.Sprintf("tunnel-%d", i)
+		var stdout, stderr bytes.Buffer
+		err := execInPod(ctx, *namespace, podName, container, probeCommand(m), nil, &stdout, &stderr)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		var exitErr utilexec.ExitError
+		if err != nil && !errors.As(err, &exitErr) {
+			// 没有 exec 权限等情况, 跳过检查
+			fmt.Printf("\033[1;33mCould not test %s: %v\033[0m\n", m, err)
+			continue
+		}
+		if err != nil {
+			reason := classifyProbeFailure(m, stdout.String()+stderr.String())
+			fmt.Printf("\033[1;31m✗ %s: %s\033[0m\n", m, reason)
+			failed = append(failed, m.String())
+			continue
+		}
+		if m.Protocol == "udp" {
+			fmt.Printf("\033[1;32m✓ %s: host resolved (UDP can not be tested further)\033[0m\n", m)
+		} else {
+			fmt.Printf("\033[1;32m✓ %s: reachable\033[0m\n", m)
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+
+	confirm := false
+	survey.AskOne(&survey.Confirm{
+		Message: fmt.Sprintf("%d target(s) are not reachable from the tunnel pod, start the tunnel anyway?", len(failed)),
+		Default: false,
+	}, &confirm)
+	if !confirm {
+		return fmt.Errorf("targets not reachable: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// TCP 目标使用 socat 建立一次连接, UDP 目标只能检查域名解析
+func probeCommand(m tunnelMapping) []string {
+	if m.Protocol == "udp" {
+		return []string{
+			"python3", "-c",
+			"import socket, sys; socket.getaddrinfo(sys.argv[1], int(sys.argv[2]), 0, socket.SOCK_DGRAM)",
+			m.Host, strconv.Itoa(m.Port),
+		}
+	}
+	return []string{
+		"socat", "-u", "OPEN:/dev/null",
+		fmt.Sprintf("TCP:%s:%d,connect-timeout=%d", m.Host, m.Port, tunnelProbeTimeout),
+	}
+}
+
+// 根据 socat/python 的错误输出给出原因
+func classifyProbeFailure(m tunnelMapping, output string) string {
+	lower := strings.ToLower(output)
+	switch {
+	case containsAny(lower, "name does not resolve", "name or service not known", "temporary failure in name resolution",
+		"no address associated", "getaddrinfo", "gaierror"):
+		return fmt.Sprintf("DNS resolution failed for %s, check the host name and namespace", m.Host)
+	case strings.Contains(lower, "connection refused"):
+		return fmt.Sprintf("connection refused, nothing is listening on %s:%d", m.Host, m.Port)
+	case strings.Contains(lower, "timed out"):
+		return fmt.Sprintf("connection timed out after %ds, it may be blocked by a NetworkPolicy or firewall", tunnelProbeTimeout)
+	case containsAny(lower, "no route to host", "network is unreachable"):
+		return fmt.Sprintf("no route to %s", m.Host)
+	}
+	if output = strings.TrimSpace(output); output != "" {
+		return output
+	}
+	return "connection failed"
+}
+
+func containsAny(s string, substrs ...string) bool {
+	for _, sub := range substrs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestTunnelPodState(t *testing.T) {
+	pod := &v1.Pod{Status: v1.PodStatus{Phase: v1.PodPending, ContainerStatuses: []v1.ContainerStatus{
+		{Name: "tunnel-0", State: v1.ContainerState{Running: &v1.ContainerStateRunning{}}},
+		{Name: "tunnel-1", State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "ErrImagePull", Message: "not found"}}},
+		{Name: "tunnel-2", State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{Reason: "Error", ExitCode: 1}}},
+	}}}
+	want := "Pod status: Pending, container tunnel-1 is waiting: ErrImagePull not found, container tunnel-2 terminated: Error  (exit code: 1)"
+	if got := tunnelPodState(pod); got != want {
+		t.Errorf("tunnelPodState() = %q, want %q", got, want)
+	}
+}
+
+// tcp 使用 socat 连接, udp 使用 python 发送一个探测包
+func TestProbeCommand(t *testing.T) {
+	tcp := probeCommand(tunnelMapping{Protocol: "tcp", Host: "db", Port: 5432})
+	if tcp[0] != "socat" || tcp[len(tcp)-1] != "TCP:db:5432,connect-timeout=5" {
+		t.Errorf("tcp probe = %v", tcp)
+	}
+	udp := probeCommand(tunnelMapping{Protocol: "udp", Host: "dns", Port: 53})
+	if udp[0] != "python3" || !reflect.DeepEqual(udp[len(udp)-2:], []string{"dns", "53"}) {
+		t.Errorf("udp probe = %v", udp)
+	}
+}
+
+func TestClassifyProbeFailure(t *testing.T) {
+	m := tunnelMapping{Host: "db", Port: 5432}
+	tests := []struct {
+		output string
+		want   string
+	}{
+		{"socat[1] E getaddrinfo(\"db\", \"NULL\", {...}): Name does not resolve", "DNS resolution failed for db"},
+		{"socket.gaierror: [Errno -2] Name or service not known", "DNS resolution failed for db"},
+		{"socat[1] E connect(5, AF=2 10.0.0.1:5432, 16): Connection refused", "connection refused, nothing is listening on db:5432"},
+		{"socat[1] E connect(5, AF=2 10.0.0.1:5432, 16): Connection timed out", "connection timed out after 5s"},
+		{"socat[1] E connect(...): No route to host", "no route to db"},
+		{"  permission denied\n", "permission denied"},
+		{"", "connection failed"},
+	}
+	for _, tt := range tests {
+		if got := classifyProbeFailure(m, tt.output); !strings.HasPrefix(got, tt.want) {
+			t.Errorf("classifyProbeFailure(%q) = %q, want prefix %q", tt.output, got, tt.want)
+		}
+	}
+}
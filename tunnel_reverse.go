@@ -0,0 +1,352 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	// 反向隧道 pod 中供 kube-ui 回连的端口, 只监听 127.0.0.1, 只能通过 port-forward 访问
+	reverseBackPort = 10999
+	// 本地保持的空闲回连数量
+	reversePoolSize = 4
+)
+
+// 反向隧道中继: 集群内的连接到达 public 端口后, 取一条 kube-ui 的空闲回连,
+// 发送 'C', kube-ui 连接本地端口成功后回复 'A', 失败回复 'E', 之后双向转发
+const reverseRelayScript = `
+import queue, socket, sys, threading
+public_port, back_port = int(sys.argv[1]), int(sys.argv[2])
+idle = queue.Queue()
+
+def pipe(a, b):
+    try:
+        while True:
+            d = a.recv(65536)
+            if not d:
+                break
+            b.sendall(d)
+    except Exception:
+        pass
+    try:
+        b.shutdown(socket.SHUT_WR)
+    except Exception:
+        pass
+
+def join(c, back):
+    t = threading.Thread(target=pipe, args=(back, c), daemon=True)
+    t.start()
+    pipe(c, back)
+    t.join()
+    c.close()
+    back.close()
+
+def handle(c):
+    while True:
+        try:
+            back = idle.get(timeout=10)
+        except queue.Empty:
+            print('no kube-ui connection available', flush=True)
+            c.close()
+            return
+        try:
+            back.sendall(b'C')
+            back.settimeout(10)
+            r = back.recv(1)
+            back.settimeout(None)
+        except Exception:
+            r = b''
+        if r == b'A':
+            join(c, back)
+            return
+        back.close()
+        if r == b'E':
+            print('local port refused the connection', flush=True)
+            c.close()
+            return
+
+def accept_back(s):
+    while True:
+        c, _ = s.accept()
+        idle.put(c)
+
+def listen(host, port):
+    s = socket.socket(socket.AF_INET, socket.SOCK_STREAM)
+    s.setsockopt(socket.SOL_SOCKET, socket.SO_REUSEADDR, 1)
+    s.bind((host, port))
+    s.listen(64)
+    return s
+
+back = listen('127.0.0.1', back_port)
+public = listen('', public_port)
+threading.Thread(target=accept_back, args=(back,), daemon=True).start()
+print('reverse relay listening on %d' % public_port, flush=True)
+while True:
+    c, _ = public.accept()
+    threading.Thread(target=handle, args=(c,), daemon=True).start()
+`
+
+// 反向隧道 pod, 监听 servicePort 对应的端口和回连端口
+func buildReversePod(listenPort, backPort int) *v1.Pod {
+	return newTunnelPod("tunnel-reverse", []v1.Container{
+		{
+			Name:            "relay",
+			Image:           relayImage(),
+			ImagePullPolicy: "IfNotPresent",
+			Command: []string{
+				"python3", "-u", "-c", reverseRelayScript,
+				strconv.Itoa(listenPort), strconv.Itoa(backPort),
+			},
+			Ports: []v1.ContainerPort{
+				{Name: "relay", ContainerPort: int32(listenPort)},
+			},
+		},
+	})
+}
+
+// 选择反向隧道 pod 的 Service
+func buildReverseService(pod *v1.Pod, servicePort, listenPort int) *v1.Service {
+	return &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pod.Name,
+			Namespace: pod.Namespace,
+			Labels:    pod.Labels,
+		},
+		Spec: v1.ServiceSpec{
+			Selector: map[string]string{
+				labelManagedBy: "kube-ui",
+				labelSession:   pod.Labels[labelSession],
+			},
+			Ports: []v1.ServicePort{
+				{
+					Name:       "relay",
+					Protocol:   v1.ProtocolTCP,
+					Port:       int32(servicePort),
+					TargetPort: intstr.FromInt32(int32(listenPort)),
+				},
+			},
+		},
+	}
+}
+
+// 反向隧道: 在集群中创建 pod 和 Service, 把到 Service 的连接转发到本地端口
+func handleTunnelReverseAction() {
+	var localInput string
+	survey.AskOne(&survey.Input{
+		Message: "Enter local port to expose to the cluster: ",
+	}, &localInput)
+	localInput = strings.TrimSpace(localInput)
+	if checkExitCode(localInput) || localInput == "" {
+		return
+	}
+	localPort, err := parsePort(localInput)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	var serviceInput string
+	survey.AskOne(&survey.Input{
+		Message: "Enter service port: ",
+		Default: strconv.Itoa(localPort),
+	}, &serviceInput)
+	servicePort, err := parsePort(serviceInput)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	runReverseTunnel(localPort, servicePort)
+}
+
+func runReverseTunnel(localPort, servicePort int) {
+	// pod 以非 root 用户运行, 监听端口规则与正向隧道相同
+	mapping := []tunnelMapping{{Port: servicePort}}
+	assignRemotePorts(mapping)
+	listenPort := mapping[0].RemotePort
+	backPort := reverseBackPort
+	if backPort == listenPort {
+		backPort++
+	}
+
+	fmt.Println("Creating reverse tunnel pod...")
+	pod, cleanup, err := startTunnelPod(buildReversePod(listenPort, backPort))
+	if err != nil {
+		fmt.Printf("Error creating tunnel pod: %v\n", err)
+		return
+	}
+	defer cleanup()
+
+	svc, cleanupService, err := startTunnelService(buildReverseService(pod, servicePort, listenPort))
+	if err != nil {
+		fmt.Printf("Error creating tunnel service: %v\n", err)
+		return
+	}
+	defer cleanupService()
+
+	if err := waitTunnelReady(pod, nil); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	forwardPort, err := freeLocalPort()
+	if err != nil {
+		fmt.Printf("Error allocating local port: %v\n", err)
+		return
+	}
+	pool := startReversePool(fmt.Sprintf("127.0.0.1:%d", forwardPort), fmt.Sprintf("127.0.0.1:%d", localPort))
+	defer pool.Close()
+
+	fmt.Printf("Reverse tunneling %s.%s.svc:%d -> localhost:%d\n", svc.Name, svc.Namespace, servicePort, localPort)
+	execCommand("port-forward", fmt.Sprintf("pod/%s", pod.Name), fmt.Sprintf("%d:%d", forwardPort, backPort))
+}
+
+// 创建 tunnel 使用的 Service, 返回的 cleanup 可以重复调用, 退出信号时也会执行
+func startTunnelService(tunnelService *v1.Service) (*v1.Service, func(), error) {
+	ctx, cancel := requestContext()
+	defer cancel()
+	svc, err := k8sClient.CoreV1().Services(*namespace).Create(ctx, tunnelService, metav1.CreateOptions{})
+	auditAPI("tunnel", "create", "service/"+tunnelService.Name, err)
+	if err != nil {
+		return nil, func() {}, err
+	}
+	var once sync.Once
+	var unregister func()
+	cleanup := func() {
+		once.Do(func() {
+			unregister()
+			fmt.Println("Cleaning up tunnel service...")
+			ctx, cancel := timeoutContext()
+			defer cancel()
+			err := k8sClient.CoreV1().Services(svc.Namespace).Delete(ctx, svc.Name, metav1.DeleteOptions{})
+			auditAPI("tunnel", "delete", "service/"+svc.Name, err)
+			if err != nil {
+				fmt.Printf("Error deleting tunnel service: %v\n", err)
+			}
+		})
+	}
+	unregister = registerCleanup(cleanup)
+	return svc, cleanup, nil
+}
+
+// 通过 port-forward 保持到 pod 回连端口的空闲连接池
+type reversePool struct {
+	forwardAddr string
+	localAddr   string
+
+	mu     sync.Mutex
+	conns  map[net.Conn]bool
+	closed bool
+}
+
+func startReversePool(forwardAddr, localAddr string) *reversePool {
+	p := &reversePool{
+		forwardAddr: forwardAddr,
+		localAddr:   localAddr,
+		conns:       map[net.Conn]bool{},
+	}
+	for i := 0; i < reversePoolSize; i++ {
+		go p.worker()
+	}
+	return p
+}
+
+func (p *reversePool) isClosed() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.closed
+}
+
+func (p *reversePool) track(conn net.Conn) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		conn.Close()
+		return false
+	}
+	p.conns[conn] = true
+	return true
+}
+
+func (p *reversePool) untrack(conn net.Conn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.conns, conn)
+	conn.Close()
+}
+
+// 保持一条空闲回连, 收到 'C' 后连接本地端口并转发, 然后建立新的回连
+func (p *reversePool) worker() {
+	for !p.isClosed() {
+		back, err := net.DialTimeout("tcp", p.forwardAddr, 5*time.Second)
+		if err != nil {
+			// port-forward 可能还没有就绪
+			time.Sleep(time.Second)
+			continue
+		}
+		if !p.track(back) {
+			return
+		}
+		op := make([]byte, 1)
+		if _, err := io.ReadFull(back, op); err != nil || op[0] != 'C' {
+			p.untrack(back)
+			time.Sleep(time.Second)
+			continue
+		}
+		local, err := net.DialTimeout("tcp", p.localAddr, 5*time.Second)
+		if err != nil {
+			fmt.Printf("Reverse tunnel: %v\n", err)
+			back.Write([]byte{'E'})
+			p.untrack(back)
+			continue
+		}
+		if _, err := back.Write([]byte{'A'}); err != nil {
+			local.Close()
+			p.untrack(back)
+			continue
+		}
+		go func() {
+			pipeConns(back, local)
+			p.untrack(back)
+		}()
+	}
+}
+
+func (p *reversePool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	for conn := range p.conns {
+		conn.Close()
+		delete(p.conns, conn)
+	}
+}
+
+// 双向转发, 一个方向结束时关闭对端的写入
+func pipeConns(a, b net.Conn) {
+	var wg sync.WaitGroup
+	wg.Add(2)
+	copyHalf := func(dst, src net.Conn) {
+		defer wg.Done()
+		io.Copy(dst, src)
+		if c, ok := dst.(interface{ CloseWrite() error }); ok {
+			c.CloseWrite()
+		} else {
+			dst.Close()
+		}
+	}
+	go copyHalf(a, b)
+	go copyHalf(b, a)
+	wg.Wait()
+	a.Close()
+	b.Close()
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"io"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestBuildReverseService(t *testing.T) {
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:      "tunnel-reverse-abc",
+		Namespace: "dev",
+		Labels:    map[string]string{labelManagedBy: "kube-ui", labelComponent: "tunnel-reverse", labelSession: "s1"},
+	}}
+	svc := buildReverseService(pod, 8080, 18080)
+	if svc.Name != pod.Name || svc.Namespace != "dev" {
+		t.Errorf("service = %s/%s, want dev/%s", svc.Namespace, svc.Name, pod.Name)
+	}
+	if want := map[string]string{labelManagedBy: "kube-ui", labelSession: "s1"}; !reflect.DeepEqual(svc.Spec.Selector, want) {
+		t.Errorf("service selector = %v, want %v", svc.Spec.Selector, want)
+	}
+	want := []v1.ServicePort{{Name: "relay", Protocol: v1.ProtocolTCP, Port: 8080, TargetPort: intstr.FromInt32(18080)}}
+	if !reflect.DeepEqual(svc.Spec.Ports, want) {
+		t.Errorf("service ports = %v, want %v", svc.Spec.Ports, want)
+	}
+}
+
+// 用本地监听代替 pod 中的回连端口, 按中继协议发送 'C' 并检查回复和转发的数据
+func TestReversePool(t *testing.T) {
+	local, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer local.Close()
+	go func() {
+		for {
+			c, err := local.Accept()
+			if err != nil {
+				return
+			}
+			go func(c net.Conn) {
+				defer c.Close()
+				data, _ := io.ReadAll(c)
+				c.Write([]byte(strings.ToUpper(string(data))))
+			}(c)
+		}
+	}()
+	refused, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	refusedAddr := refused.Addr().String()
+	refused.Close()
+
+	tests := []struct {
+		name      string
+		localAddr string
+		wantReply byte
+		wantData  string
+	}{
+		{"forwarded", local.Addr().String(), 'A', "HELLO"},
+		{"local port refused", refusedAddr, 'E', ""},
+	}
+	for _, tt := range tests {
+		back, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		pool := startReversePool(back.Addr().String(), tt.localAddr)
+		conn, err := back.Accept()
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn.SetDeadline(time.Now().Add(10 * time.Second))
+		conn.Write([]byte{'C'})
+		reply := make([]byte, 1)
+		if _, err := io.ReadFull(conn, reply); err != nil || reply[0] != tt.wantReply {
+			t.Errorf("%s: reply = %q, %v, want %q", tt.name, reply, err, tt.wantReply)
+		}
+		if tt.wantReply == 'A' {
+			conn.Write([]byte("hello"))
+			conn.(*net.TCPConn).CloseWrite()
+			data, _ := io.ReadAll(conn)
+			if string(data) != tt.wantData {
+				t.Errorf("%s: forwarded data = %q, want %q", tt.name, data, tt.wantData)
+			}
+		}
+		conn.Close()
+		pool.Close()
+		back.Close()
+	}
+}
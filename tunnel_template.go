@@ -0,0 +1,250 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/spf13/cobra"
+)
+
+// 保存在 .kube-ui 中的常用隧道, 对应 tunnels 列表中的一项
+type TunnelTemplate struct {
+	Name      string `json:"name"`
+	Target    string `json:"target"`              // host:port 或 udp://host:port
+	LocalPort int    `json:"localPort,omitempty"` // 默认与目标端口相同
+	Namespace string `json:"namespace,omitempty"` // tunnel pod 所在的命名空间, 默认使用当前命名空间
+}
+
+func (t TunnelTemplate) String() string {
+	s := fmt.Sprintf("%s (%s", t.Name, t.Target)
+	if t.LocalPort != 0 {
+		s += fmt.Sprintf(" on localhost:%d", t.LocalPort)
+	}
+	if t.Namespace != "" {
+		s += fmt.Sprintf(", namespace %s", t.Namespace)
+	}
+	return s + ")"
+}
+
+// 把模板转换为隧道映射
+func (t TunnelTemplate) mapping() (tunnelMapping, error) {
+	if strings.TrimSpace(t.Target) == "" || len(strings.Fields(t.Target)) != 1 || strings.Contains(t.Target, "=") {
+		return tunnelMapping{}, fmt.Errorf("tunnel %q: invalid target %q, please use host:port or udp://host:port", t.Name, t.Target)
+	}
+	mappings, err := parseTunnelMappings(t.Target)
+	if err != nil {
+		return tunnelMapping{}, fmt.Errorf("tunnel %q: %v", t.Name, err)
+	}
+	m := mappings[0]
+	m.LocalPort = m.Port
+	if t.LocalPort != 0 {
+		if _, err := parsePort(strconv.Itoa(t.LocalPort)); err != nil {
+			return tunnelMapping{}, fmt.Errorf("tunnel %q: %v", t.Name, err)
+		}
+		m.LocalPort = t.LocalPort
+	}
+	return m, nil
+}
+
+// 把多个模板合并为一次隧道会话, 所有模板必须在同一个命名空间
+func templateMappings(templates []TunnelTemplate, defaultNamespace string) ([]tunnelMapping, string, error) {
+	var mappings []tunnelMapping
+	ns := ""
+	localPorts := map[string]string{}
+	for _, t := range templates {
+		m, err := t.mapping()
+		if err != nil {
+			return nil, "", err
+		}
+		key := fmt.Sprintf("%s/%d", m.Protocol, m.LocalPort)
+		if other, ok := localPorts[key]; ok {
+			return nil, "", fmt.Errorf("tunnels %q and %q both use local port %d", other, t.Name, m.LocalPort)
+		}
+		localPorts[key] = t.Name
+
+		tns := t.Namespace
+		if tns == "" {
+			tns = defaultNamespace
+		}
+		if ns != "" && tns != ns {
+			return nil, "", fmt.Errorf("tunnel %q uses namespace %s but the others use %s, please start them separately", t.Name, tns, ns)
+		}
+		ns = tns
+		mappings = append(mappings, m)
+	}
+	assignRemotePorts(mappings)
+	return mappings, ns, nil
+}
+
+// 在模板指定的命名空间中启动隧道
+func runTunnelTemplates(templates []TunnelTemplate) {
+	mappings, ns, err := templateMappings(templates, *namespace)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	previous := *namespace
+	*namespace = ns
+	defer func() { *namespace = previous }()
+	runTunnelSession(mappings)
+}
+
+// tunnel 菜单中选择保存的隧道, 可以多选
+func handleTunnelTemplateAction() {
+	var options []string
+	for _, t := range currentConfig.Tunnels {
+		options = append(options, t.String())
+	}
+	var selected []int
+	prompt := &survey.MultiSelect{
+		Message: "choose saved tunnels:",
+		Options: options,
+	}
+	if err := survey.AskOne(prompt, &selected); err != nil || len(selected) == 0 {
+		return
+	}
+	var templates []TunnelTemplate
+	for _, i := range selected {
+		templates = append(templates, currentConfig.Tunnels[i])
+	}
+	runTunnelTemplates(templates)
+}
+
+// tunnel 的子命令, 在 init 中设置, 避免初始化循环
+var tunnelSubcommands func() []*cobra.Command
+
+// 与 tunnel 子命令同名的隧道不能用 kube-ui tunnel <name> 启动
+func isTunnelSubcommand(name string) bool {
+	for _, c := range tunnelSubcommands() {
+		if c.Name() == name || c.HasAlias(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// kube-ui tunnel [run] <name...>, 没有参数时列出保存的隧道
+func runTunnelCommand(names []string) {
+	defer line.Close()
+	config, err := readKubeUIConfig()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	// 使用 --kubeconfig 或 --config 时只查找对应的配置项
+	var configs []KubeConfig
+	for _, cfg := range config.Configs {
+		if *kubeConfig != "" && cfg.Path != *kubeConfig {
+			continue
+		}
+		if *configName != "" && cfg.Name != *configName {
+			continue
+		}
+		configs = append(configs, cfg)
+	}
+
+	if len(names) == 0 {
+		printTunnelTemplates(configs)
+		return
+	}
+
+	cfg, templates, err := findTunnelTemplates(configs, names)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	currentConfig = cfg
+	*kubeConfig = cfg.Path
+	if *namespace == "" {
+		*namespace = cfg.Namespace
+	}
+	if *namespace == "" {
+		*namespace = "default"
+	}
+	if err := initKubeClient(); err != nil {
+		fmt.Println(err)
+		return
+	}
+	runTunnelTemplates(templates)
+}
+
+// 查找包含所有名称的配置项, 多个配置项都有时让用户选择
+func findTunnelTemplates(configs []KubeConfig, names []string) (KubeConfig, []TunnelTemplate, error) {
+	var matched []KubeConfig
+	var matchedTemplates [][]TunnelTemplate
+	for _, cfg := range configs {
+		var templates []TunnelTemplate
+		for _, name := range names {
+			for _, t := range cfg.Tunnels {
+				if t.Name == name {
+					templates = append(templates, t)
+					break
+				}
+			}
+		}
+		if len(templates) == len(names) {
+			matched = append(matched, cfg)
+			matchedTemplates = append(matchedTemplates, templates)
+		}
+	}
+
+	switch len(matched) {
+	case 0:
+		return KubeConfig{}, nil, fmt.Errorf("tunnel %s not found in ~/.kube-ui, run 'kube-ui tunnel' to list saved tunnels", strings.Join(names, ", "))
+	case 1:
+		return matched[0], matchedTemplates[0], nil
+	}
+
+	var options []string
+	for _, cfg := range matched {
+		displayName := cfg.Name
+		if cfg.Comment != "" {
+			displayName += fmt.Sprintf(" (%s)", cfg.Comment)
+		}
+		options = append(options, displayName)
+	}
+	var selectedIndex int
+	prompt := &survey.Select{
+		Message: fmt.Sprintf("tunnel %s is saved in several configs, choose one:", strings.Join(names, ", ")),
+		Options: options,
+	}
+	if err := survey.AskOne(prompt, &selectedIndex); err != nil {
+		return KubeConfig{}, nil, fmt.Errorf("tunnel %s is ambiguous, please use --config or --kubeconfig to choose one", strings.Join(names, ", "))
+	}
+	return matched[selectedIndex], matchedTemplates[selectedIndex], nil
+}
+
+func printTunnelTemplates(configs []KubeConfig) {
+	table := newOutputTable("Config", "Name", "Target", "Local Port", "Namespace")
+	count := 0
+	var shadowed []string
+	for _, cfg := range configs {
+		for _, t := range cfg.Tunnels {
+			localPort := "same as target"
+			if t.LocalPort != 0 {
+				localPort = strconv.Itoa(t.LocalPort)
+			}
+			ns := t.Namespace
+			if ns == "" {
+				ns = cfg.Namespace
+			}
+			table.append(nil, cfg.Name, t.Name, t.Target, localPort, ns)
+			count++
+			if isTunnelSubcommand(t.Name) && !slices.Contains(shadowed, t.Name) {
+				shadowed = append(shadowed, t.Name)
+			}
+		}
+	}
+	if count == 0 {
+		fmt.Println("No saved tunnels, add them to \"tunnels\" in ~/.kube-ui, for example:")
+		fmt.Println(`  "tunnels": [{"name": "rds", "target": "prod-rds.internal:5432", "localPort": 5432, "namespace": "default"}]`)
+		return
+	}
+	table.render()
+	for _, name := range shadowed {
+		fmt.Printf("Tunnel %q has the same name as a subcommand, start it with 'kube-ui tunnel run %s'\n", name, name)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTemplateMappings(t *testing.T) {
+	tests := []struct {
+		name      string
+		templates []TunnelTemplate
+		want      []tunnelMapping
+		wantNS    string
+		wantErr   bool
+	}{
+		{
+			name:      "default local port and namespace",
+			templates: []TunnelTemplate{{Name: "db", Target: "db:5432"}},
+			want:      []tunnelMapping{{Protocol: "tcp", LocalPort: 5432, Host: "db", Port: 5432, RemotePort: 5432}},
+			wantNS:    "default",
+		},
+		{
+			name: "several templates in one namespace",
+			templates: []TunnelTemplate{
+				{Name: "db", Target: "db:5432", LocalPort: 15432, Namespace: "prod"},
+				{Name: "dns", Target: "udp://kube-dns:53", Namespace: "prod"},
+			},
+			want: []tunnelMapping{
+				{Protocol: "tcp", LocalPort: 15432, Host: "db", Port: 5432, RemotePort: 5432},
+				{Protocol: "udp", LocalPort: 53, Host: "kube-dns", Port: 53, RemotePort: 10053},
+			},
+			wantNS: "prod",
+		},
+		{
+			name: "different namespaces",
+			templates: []TunnelTemplate{
+				{Name: "db", Target: "db:5432", Namespace: "prod"},
+				{Name: "cache", Target: "cache:6379", Namespace: "dev"},
+			},
+			wantErr: true,
+		},
+		{name: "target with local port", templates: []TunnelTemplate{{Name: "a", Target: "80=a:80"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, ns, err := templateMappings(tt.templates, "default")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: templateMappings error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) || ns != tt.wantNS {
+			t.Errorf("%s: templateMappings = %+v, %q, want %+v, %q", tt.name, got, ns, tt.want, tt.wantNS)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestParseTunnelMappings(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    []tunnelMapping
+		wantErr bool
+	}{
+		{"db:5432", []tunnelMapping{{Protocol: "tcp", Host: "db", Port: 5432, RemotePort: 5432}}, false},
+		{"15432=db:5432", []tunnelMapping{{Protocol: "tcp", LocalPort: 15432, Host: "db", Port: 5432, RemotePort: 5432}}, false},
+		{"5432=db:5432 53=udp://dns:53", []tunnelMapping{
+			{Protocol: "tcp", LocalPort: 5432, Host: "db", Port: 5432, RemotePort: 5432},
+			{Protocol: "udp", LocalPort: 53, Host: "dns", Port: 53, RemotePort: 10053},
+		}, false},
+		{"db", nil, true},
+		{"db:99999", nil, true},
+		{"5432=db:5432 5432=db2:5432", nil, true},
+		{"5432=db:5432 cache:6379", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := parseTunnelMappings(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseTunnelMappings(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseTunnelMappings(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestAssignRemotePorts(t *testing.T) {
+	tests := []struct {
+		ports []int
+		want  []int
+	}{
+		{[]int{5432, 5432, 5432}, []int{5432, 5433, 5434}},
+		{[]int{80, 443, 10080}, []int{10080, 10443, 10081}},
+		{[]int{65535, 65535}, []int{65535, 1024}},
+	}
+	for _, tt := range tests {
+		var mappings []tunnelMapping
+		for _, p := range tt.ports {
+			mappings = append(mappings, tunnelMapping{Port: p})
+		}
+		assignRemotePorts(mappings)
+		var got []int
+		for _, m := range mappings {
+			got = append(got, m.RemotePort)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("assignRemotePorts(%v) = %v, want %v", tt.ports, got, tt.want)
+		}
+	}
+}
+
+// 默认使用受限的安全设置, 配置中的调度和资源覆盖默认值, 但不能覆盖 kube-ui 自己的标签
+func TestApplyTunnelPodProfile(t *testing.T) {
+	newPod := func() *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{labelManagedBy: "kube-ui"}},
+			Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "tunnel-0"}, {Name: "tunnel-1"}}},
+		}
+	}
+
+	pod := newPod()
+	applyTunnelPodProfile(pod, nil)
+	sc := pod.Spec.SecurityContext
+	if !*sc.RunAsNonRoot || *sc.RunAsUser != defaultTunnelUser || *pod.Spec.AutomountServiceAccountToken {
+		t.Errorf("default pod security context = %+v", sc)
+	}
+	for _, c := range pod.Spec.Containers {
+		if *c.SecurityContext.AllowPrivilegeEscalation || !reflect.DeepEqual(c.SecurityContext.Capabilities.Drop, []v1.Capability{"ALL"}) {
+			t.Errorf("container %s security context = %+v", c.Name, c.SecurityContext)
+		}
+	}
+
+	resources := &v1.ResourceRequirements{Limits: v1.ResourceList{v1.ResourceMemory: resource.MustParse("1Gi")}}
+	pod = newPod()
+	applyTunnelPodProfile(pod, &TunnelPodConfig{
+		NodeSelector: map[string]string{"pool": "tools"},
+		Resources:    resources,
+		RunAsUser:    ptr.To(int64(1000)),
+		Labels:       map[string]string{labelManagedBy: "someone", "team": "web"},
+	})
+	if pod.Spec.NodeSelector["pool"] != "tools" || *pod.Spec.SecurityContext.RunAsUser != 1000 ||
+		!reflect.DeepEqual(pod.Spec.Containers[1].Resources, *resources) {
+		t.Errorf("configured pod spec = %+v", pod.Spec)
+	}
+	if pod.Labels[labelManagedBy] != "kube-ui" || pod.Labels["team"] != "web" {
+		t.Errorf("configured pod labels = %v", pod.Labels)
+	}
+}
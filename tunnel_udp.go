@@ -0,0 +1,208 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// 默认的 UDP 中继镜像, 中继脚本使用 python 标准库
+const defaultRelayImage = "python:3.12-alpine"
+
+// UDP 会话空闲超时时间
+const udpSessionTimeout = 5 * time.Minute
+
+// pod 中的 UDP 中继: 监听 TCP 端口, 把带 2 字节长度前缀的帧转换为 UDP 报文, 反方向同理
+const udpRelayScript = `
+import socket, struct, sys, threading
+listen_port, host, port = int(sys.argv[1]), sys.argv[2], int(sys.argv[3])
+
+def read_exact(c, n):
+    buf = b''
+    while len(buf) < n:
+        d = c.recv(n - len(buf))
+        if not d:
+            raise EOFError()
+        buf += d
+    return buf
+
+def upstream(c, u):
+    try:
+        while True:
+            n = struct.unpack('!H', read_exact(c, 2))[0]
+            u.send(read_exact(c, n))
+    except Exception:
+        pass
+    c.close()
+    u.close()
+
+def downstream(c, u):
+    try:
+        while True:
+            d = u.recv(65535)
+            c.sendall(struct.pack('!H', len(d)) + d)
+    except Exception:
+        pass
+    c.close()
+
+def handle(c):
+    try:
+        family, socktype, proto, _, addr = socket.getaddrinfo(host, port, 0, socket.SOCK_DGRAM)[0]
+        u = socket.socket(family, socktype, proto)
+        u.connect(addr)
+    except Exception as e:
+        print('udp relay: %s' % e, flush=True)
+        c.close()
+        return
+    threading.Thread(target=upstream, args=(c, u), daemon=True).start()
+    threading.Thread(target=downstream, args=(c, u), daemon=True).start()
+
+s = socket.socket(socket.AF_INET, socket.SOCK_STREAM)
+s.setsockopt(socket.SOL_SOCKET, socket.SO_REUSEADDR, 1)
+s.bind(('', listen_port))
+s.listen(64)
+print('udp relay listening on %d -> %s:%d' % (listen_port, host, port), flush=True)
+while True:
+    c, _ = s.accept()
+    handle(c)
+`
+
+// 当前配置中的 UDP 中继镜像
+func relayImage() string {
+	if currentConfig.RelayImage != "" {
+		return currentConfig.RelayImage
+	}
+	return defaultRelayImage
+}
+
+// pod 中的 UDP 中继容器
+func udpRelayContainer(name string, m tunnelMapping) v1.Container {
+	return v1.Container{
+		Name:            name,
+		Image:           relayImage(),
+		ImagePullPolicy: "IfNotPresent",
+		Command: []string{
+			"python3", "-u", "-c", udpRelayScript,
+			strconv.Itoa(m.RemotePort), m.Host, strconv.Itoa(m.Port),
+		},
+	}
+}
+
+// 获取一个空闲的本地 TCP 端口, 用于 UDP 隧道的 port-forward
+func freeLocalPort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+// 本地 UDP 中继: 每个 UDP 客户端对应一条到 port-forward 端口的 TCP 连接
+type udpRelay struct {
+	conn        *net.UDPConn
+	forwardAddr string
+
+	mu       sync.Mutex
+	sessions map[string]net.Conn
+	closed   bool
+}
+
+func startUDPRelay(localPort, forwardPort int) (*udpRelay, error) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: localPort})
+	if err != nil {
+		return nil, err
+	}
+	r := &udpRelay{
+		conn:        conn,
+		forwardAddr: fmt.Sprintf("127.0.0.1:%d", forwardPort),
+		sessions:    map[string]net.Conn{},
+	}
+	go r.serve()
+	return r, nil
+}
+
+func (r *udpRelay) serve() {
+	buf := make([]byte, 65535)
+	for {
+		n, client, err := r.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		session, err := r.session(client)
+		if err != nil {
+			fmt.Printf("UDP relay: %v\n", err)
+			continue
+		}
+		frame := make([]byte, 2+n)
+		binary.BigEndian.PutUint16(frame, uint16(n))
+		copy(frame[2:], buf[:n])
+		if _, err := session.Write(frame); err != nil {
+			r.closeSession(client.String(), session)
+		}
+	}
+}
+
+// 获取或创建客户端对应的 TCP 连接
+func (r *udpRelay) session(client *net.UDPAddr) (net.Conn, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil, fmt.Errorf("relay closed")
+	}
+	if session, ok := r.sessions[client.String()]; ok {
+		return session, nil
+	}
+	session, err := net.DialTimeout("tcp", r.forwardAddr, 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	r.sessions[client.String()] = session
+	go r.reply(client, session)
+	return session, nil
+}
+
+// 把 TCP 连接上的帧还原为 UDP 报文发回给客户端
+func (r *udpRelay) reply(client *net.UDPAddr, session net.Conn) {
+	defer r.closeSession(client.String(), session)
+	header := make([]byte, 2)
+	for {
+		session.SetReadDeadline(time.Now().Add(udpSessionTimeout))
+		if _, err := io.ReadFull(session, header); err != nil {
+			return
+		}
+		payload := make([]byte, binary.BigEndian.Uint16(header))
+		if _, err := io.ReadFull(session, payload); err != nil {
+			return
+		}
+		if _, err := r.conn.WriteToUDP(payload, client); err != nil {
+			return
+		}
+	}
+}
+
+func (r *udpRelay) closeSession(key string, session net.Conn) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.sessions[key] == session {
+		delete(r.sessions, key)
+	}
+	session.Close()
+}
+
+func (r *udpRelay) Close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	r.conn.Close()
+	for key, session := range r.sessions {
+		session.Close()
+		delete(r.sessions, key)
+	}
+}
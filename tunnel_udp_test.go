@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildTunnelPodUDP(t *testing.T) {
+	defer func(cfg KubeConfig) { currentConfig = cfg }(currentConfig)
+	currentConfig = KubeConfig{}
+	pod := buildTunnelPod([]tunnelMapping{
+		{Protocol: "tcp", Host: "db", Port: 5432, RemotePort: 5432},
+		{Protocol: "udp", Host: "dns", Port: 53, RemotePort: 10053},
+	})
+	if len(pod.Spec.Containers) != 2 {
+		t.Fatalf("buildTunnelPod() has %d containers, want 2", len(pod.Spec.Containers))
+	}
+	tcp, udp := pod.Spec.Containers[0], pod.Spec.Containers[1]
+	if want := []string{"socat", "TCP-LISTEN:5432,fork,reuseaddr", "TCP:db:5432"}; !reflect.DeepEqual(tcp.Command, want) {
+		t.Errorf("tcp container command = %v, want %v", tcp.Command, want)
+	}
+	if udp.Name != "tunnel-1" || udp.Image != defaultRelayImage {
+		t.Errorf("udp container = %s %s, want tunnel-1 %s", udp.Name, udp.Image, defaultRelayImage)
+	}
+	if n := len(udp.Command); n < 3 || !reflect.DeepEqual(udp.Command[n-3:], []string{"10053", "dns", "53"}) {
+		t.Errorf("udp container command args = %v, want 10053 dns 53", udp.Command)
+	}
+
+	currentConfig = KubeConfig{RelayImage: "registry.local/python:3"}
+	if got := relayImage(); got != "registry.local/python:3" {
+		t.Errorf("relayImage() = %q, want the configured image", got)
+	}
+}
+
+// 本地 UDP 中继和 pod 中的中继之间使用 2 字节长度前缀的帧, 这里用一个回显大写内容的 TCP 服务代替 pod
+func TestUDPRelay(t *testing.T) {
+	forward, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer forward.Close()
+	go func() {
+		for {
+			c, err := forward.Accept()
+			if err != nil {
+				return
+			}
+			go func(c net.Conn) {
+				defer c.Close()
+				header := make([]byte, 2)
+				for {
+					if _, err := io.ReadFull(c, header); err != nil {
+						return
+					}
+					payload := make([]byte, binary.BigEndian.Uint16(header))
+					if _, err := io.ReadFull(c, payload); err != nil {
+						return
+					}
+					payload = bytes.ToUpper(payload)
+					binary.BigEndian.PutUint16(header, uint16(len(payload)))
+					c.Write(append(header, payload...))
+				}
+			}(c)
+		}
+	}()
+
+	localPort, err := freeLocalPort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	relay, err := startUDPRelay(localPort, forward.Addr().(*net.TCPAddr).Port)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer relay.Close()
+
+	client, err := net.DialUDP("udp", nil, &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: localPort})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	buf := make([]byte, 65535)
+	for _, msg := range []string{"ping", "", strings.Repeat("x", 4096)} {
+		if _, err := client.Write([]byte(msg)); err != nil {
+			t.Fatal(err)
+		}
+		client.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, err := client.Read(buf)
+		if err != nil {
+			t.Fatalf("reading reply to %d bytes: %v", len(msg), err)
+		}
+		if got, want := string(buf[:n]), strings.ToUpper(msg); got != want {
+			t.Errorf("reply = %q, want %q", got, want)
+		}
+	}
+	relay.mu.Lock()
+	sessions := len(relay.sessions)
+	relay.mu.Unlock()
+	if sessions != 1 {
+		t.Errorf("relay has %d sessions, want 1 for a single client", sessions)
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/tools/cache"
+)
+
+// 变化的行高亮显示的时间
+const watchHighlightDuration = 5 * time.Second
+
+const (
+	watchAdded   = "\033[1;32m"
+	watchChanged = "\033[1;33m"
+	watchDeleted = "\033[1;31m"
+)
+
+type watchChange struct {
+	color string
+	at    time.Time
+	obj   interface{} // 已删除的对象, 高亮期间继续显示
+}
+
+// 监听资源列表, 有变化时重新输出表格, 新增/修改/删除的行高亮显示, Ctrl+C 停止
+func watchResourceTable(resource string) {
+	informer, err := resourceInformer(*namespace, resource)
+	if err != nil {
+		fmt.Printf("Error watching %s: %v\n", resource, err)
+		return
+	}
+
+	var mu sync.Mutex
+	changes := map[string]watchChange{}
+	changed := make(chan struct{}, 1)
+	record := func(obj interface{}, color string) {
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+		change := watchChange{color: color, at: time.Now()}
+		if color == watchDeleted {
+			change.obj = obj
+		}
+		mu.Lock()
+		changes[objectKey(obj)] = change
+		mu.Unlock()
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	}
+	synced := false
+	registration, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			// 注册时会收到已有对象的 Add 事件, 不需要高亮
+			mu.Lock()
+			highlight := synced
+			mu.Unlock()
+			if highlight {
+				record(obj, watchAdded)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			// 定期 resync 时对象没有变化
+			if objectResourceVersion(oldObj) != objectResourceVersion(newObj) {
+				record(newObj, watchChanged)
+			}
+		},
+		DeleteFunc: func(obj interface{}) { record(obj, watchDeleted) },
+	})
+	if err != nil {
+		fmt.Printf("Error watching %s: %v\n", resource, err)
+		return
+	}
+	defer informer.RemoveEventHandler(registration)
+	cache.WaitForCacheSync(nil, registration.HasSynced)
+	mu.Lock()
+	synced = true
+	mu.Unlock()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt)
+	defer signal.Stop(sigChan)
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		mu.Lock()
+		objs := sortedInformerObjects(informer)
+		colors := map[string]string{}
+		for key, change := range changes {
+			if time.Since(change.at) > watchHighlightDuration {
+				delete(changes, key)
+				continue
+			}
+			colors[key] = change.color
+			if change.obj != nil {
+				objs = append(objs, change.obj)
+			}
+		}
+		mu.Unlock()
+		printWatchTable(resource, objs, colors)
+
+		select {
+		case <-sigChan:
+			fmt.Println()
+			return
+		case <-changed:
+		case <-ticker.C:
+		}
+	}
+}
+
+func objectResourceVersion(obj interface{}) string {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return ""
+	}
+	return accessor.GetResourceVersion()
+}
+
+func printWatchTable(resource string, objs []interface{}, colors map[string]string) {
+	table := informerTable(resource, objs, "")
+	for i, obj := range table.objects {
+		color, ok := colors[objectKey(obj)]
+		if !ok {
+			continue
+		}
+		for j, cell := range table.rows[i] {
+			table.rows[i][j] = color + cell + "\033[0m"
+		}
+	}
+	var buf bytes.Buffer
+	// 清屏后输出, 避免刷新时闪烁
+	buf.WriteString("\033[H\033[2J")
+	fmt.Fprintf(&buf, "Watching %s in namespace %s at %s, press Ctrl+C to stop\n", resource, *namespace, time.Now().Format("15:04:05"))
+	fmt.Fprintf(&buf, "%sadded\033[0m %schanged\033[0m %sdeleted\033[0m\n", watchAdded, watchChanged, watchDeleted)
+	format := *outputFormat
+	if format != "wide" {
+		format = "table"
+	}
+	table.write(&buf, format)
+	os.Stdout.Write(buf.Bytes())
+}
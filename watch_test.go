@@ -0,0 +1,28 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// 监听和 TUI 都使用 informer 中的对象生成表格, 行和对象一一对应
+func TestInformerTable(t *testing.T) {
+	objs := []interface{}{
+		&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "dev"}},
+		&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "api-0", Namespace: "dev"}},
+		&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "api-1", Namespace: "dev"}},
+	}
+	table := informerTable("pods", objs, "api")
+	if len(table.rows) != 2 || table.rows[0][1] != "api-0" || table.rows[1][1] != "api-1" {
+		t.Fatalf("filtered rows = %v", table.rows)
+	}
+	if !reflect.DeepEqual(table.objects, objs[1:]) {
+		t.Errorf("objects do not match the rows: %v", table.objects)
+	}
+	if table := informerTable("unknown", objs, ""); len(table.rows) != 0 {
+		t.Errorf("unknown resource rows = %v, want none", table.rows)
+	}
+}